@@ -4,7 +4,13 @@ package bitblock
 
 
 import (
+	"bytes"
+	"errors"
+	"math"
+	"math/bits"
+	"math/rand"
 	"strconv"
+	"sync"
 	"unsafe"
 )
 
@@ -20,6 +26,47 @@ func panicMessageNegativeSize(size int) string {
 	return "size (" + strconv.Itoa(size) + ") cannot be negative"
 }
 
+// panicMessageNegativePosition returns the message that should
+// appear within a panic, which will be raised because an attempt
+// was made to access a negative position of some structure.
+//
+// The message will indicate the position that was passed.
+func panicMessageNegativePosition(pos int) string {
+	return "position (" + strconv.Itoa(pos) + ") cannot be negative"
+}
+
+// panicMessageNegativeValue returns the message that should
+// appear within a panic, which will be raised because some
+// function or method was passed a value that cannot be negative,
+// but it was.
+//
+// The message will indicate the name of the parameter and the
+// value that was passed.
+func panicMessageNegativeValue(name string, value int) string {
+	return name + " (" + strconv.Itoa(value) + ") cannot be negative"
+}
+
+// panicMessageNonPositiveValue returns the message that should
+// appear within a panic, which will be raised because some
+// function or method was passed a value that must be positive
+// (greater than 0), but it was not.
+//
+// The message will indicate the name of the parameter and the
+// value that was passed.
+func panicMessageNonPositiveValue(name string, value int) string {
+	return name + " (" + strconv.Itoa(value) + ") must be positive"
+}
+
+// panicMessageNegativeShift returns the message that should
+// appear within a panic, which will be raised because an attempt
+// was made to shift the bits of a BitBlock a negative number of
+// positions.
+//
+// The message will indicate the number of positions passed.
+func panicMessageNegativeShift(k int) string {
+	return "shift count (" + strconv.Itoa(k) + ") cannot be negative"
+}
+
 // panicMessageInvalidValueOutOfRange returns the message that
 // should appear within a panic, which will be raised because
 // some function or method was passed a value that is not within
@@ -31,6 +78,16 @@ func panicMessageInvalidValueOutOfRange(minValue int, maxValue int, value int) s
 	return "invalid value (" + strconv.Itoa(value) + "), only values between " + strconv.Itoa(minValue) + " and " + strconv.Itoa(maxValue) + " (both inclusive) are allowed"
 }
 
+// panicMessageInvalidFractionOutOfRange returns the message
+// that should appear within a panic, which will be raised
+// because some function or method was passed a fraction (or
+// probability) that is not between 0 and 1.
+//
+// The message will indicate the value passed to the function.
+func panicMessageInvalidFractionOutOfRange(value float64) string {
+	return "invalid fraction (" + strconv.FormatFloat(value, 'g', -1, 64) + "), only values between 0 and 1 (both inclusive) are allowed"
+}
+
 // panicMessageInvalidIndexOverBitBlock returns the message
 // that will appear within a panic that will be raised because
 // an invalid index was passed to a method from BitBlock.
@@ -57,6 +114,33 @@ func panicMessageInvalidRangeOverBitBlock(size int, l int, r int) string {
 	return message
 }
 
+// panicMessageDifferentBitBlockSizes returns the message that
+// should appear within a panic, which will be raised because
+// some function or method was passed BitBlocks that must have
+// the same size, but they do not.
+//
+// The message will indicate the sizes of the BitBlocks.
+func panicMessageDifferentBitBlockSizes(size1 int, size2 int) string {
+	return "BitBlocks with different sizes (" + strconv.Itoa(size1) + " and " + strconv.Itoa(size2) + "), both BitBlocks must have the same size"
+}
+
+// panicMessageDifferentSignatureLengths returns the message that
+// should appear within a panic, which will be raised because two
+// signatures that must have the same length were passed to some
+// function, but they do not.
+//
+// The message will indicate the lengths of the signatures.
+func panicMessageDifferentSignatureLengths(length1 int, length2 int) string {
+	return "signatures with different lengths (" + strconv.Itoa(length1) + " and " + strconv.Itoa(length2) + "), both signatures must have the same length"
+}
+
+// panicMessageNoBitBlocks returns the message that should appear
+// within a panic, which will be raised because some function was
+// passed an empty list of BitBlocks, but it needs at least one.
+func panicMessageNoBitBlocks() string {
+	return "no BitBlocks were passed, at least one BitBlock is required"
+}
+
 // panicMessageInvalidNumberOfBitsToDiscardOverBitBlock returns
 // the message that should appear within a panic, which will be
 // raised beacuse an invalid number of bits to discard within a
@@ -80,6 +164,27 @@ func panicMessageInvalidBitBlockSizeToConvertToInteger(typeName string, bitBlock
 	return "invalid BitBlock size, BitBlock with size " + strconv.Itoa(bitBlockSize) + "cannot be converted to " + typeName
 }
 
+// panicMessageBitBlockTooLargeForUint32Indices returns the
+// message that should appear within a panic, which will be
+// raised because an attempt was made to represent the positions
+// of a BitBlock as uint32 values, but the BitBlock has positions
+// that do not fit in an uint32.
+//
+// The message will indicate the size of the BitBlock.
+func panicMessageBitBlockTooLargeForUint32Indices(bitBlockSize int) string {
+	return "BitBlock with size " + strconv.Itoa(bitBlockSize) + " has positions that cannot be represented as uint32"
+}
+
+// panicMessageInvalidEncodedBitBlock returns the message that
+// should appear within a panic, which will be raised because an
+// attempt was made to decode a BitBlock from invalid data.
+//
+// The message will indicate the name of the encoding and the
+// reason why the data is invalid.
+func panicMessageInvalidEncodedBitBlock(encoding string, reason string) string {
+	return "invalid " + encoding + " data for BitBlock, " + reason
+}
+
 // FirstBitsSet1Uint8 returns an 8-bit unsigned integer
 // (uint8) in which only the k least significant bits
 // are set to 1, the rest are set to 0. This function
@@ -164,6 +269,44 @@ func LastBitsSet1Uint64(k int) uint64 {
 	}
 }
 
+// countSetBits returns the number of bits set to 1 in the
+// slice of bytes data.
+func countSetBits(data []byte) int {
+	count := 0
+	for _, b := range data {
+		count += bits.OnesCount8(b)
+	}
+	return count
+}
+
+// copyBits copies n bits from src, starting at the position
+// srcPos, into dst, starting at the position dstPos, where the
+// bit at position i is the bit i mod 8 of the byte i / 8. The
+// bits are copied up to 8 at a time, from the lower positions to
+// the higher ones, so the ranges must not overlap unless
+// dstPos <= srcPos.
+func copyBits(dst []byte, dstPos int, src []byte, srcPos int, n int) {
+	for n > 0 {
+		k := 8
+		if n < k {
+			k = n
+		}
+		i, shift := srcPos >> 3, uint(srcPos & 7)
+		b := src[i] >> shift
+		if shift > 0 && i + 1 < len(src) {
+			b |= src[i + 1] << (8 - shift)
+		}
+		mask := FirstBitsSet1Uint8(k)
+		b &= mask
+		j, shift := dstPos >> 3, uint(dstPos & 7)
+		dst[j] = (dst[j] &^ (mask << shift)) | (b << shift)
+		if shift + uint(k) > 8 {
+			dst[j + 1] = (dst[j + 1] &^ (mask >> (8 - shift))) | (b >> (8 - shift))
+		}
+		srcPos, dstPos, n = srcPos + k, dstPos + k, n - k
+	}
+}
+
 // A BitBlock represents a sequence of bits, which allows
 // each bit to be read and modified individually.
 //
@@ -181,14 +324,24 @@ type BitBlock struct {
 // NewZeroBitBlock returns a new BitBlock with all bits
 // set to 0. NewZeroBitBlock panics if size < 0.
 func NewZeroBitBlock(size int) *BitBlock {
+	bitBlock, err := TryNewZeroBitBlock(size)
+	if err != nil {
+		panic(err.Error())
+	}
+	return bitBlock
+}
+
+// TryNewZeroBitBlock is like NewZeroBitBlock, but it returns
+// an error instead of panicking if size < 0.
+func TryNewZeroBitBlock(size int) (*BitBlock, error) {
 	if size < 0 {
-		panic(panicMessageNegativeSize(size))
+		return nil, errors.New(panicMessageNegativeSize(size))
 	}
 	bits := make([]byte, (size + 7) / 8)
 	return &BitBlock{
 		bits: bits,
 		size: size,
-	}
+	}, nil
 }
 
 // BytesToBitBlock returns a new BitBlock, which will contain a
@@ -197,8 +350,18 @@ func NewZeroBitBlock(size int) *BitBlock {
 // remaining bits will be set to 0. BytesToBitBlock panics if
 // size < 0.
 func BytesToBitBlock(src []byte, size int) *BitBlock {
+	bitBlock, err := TryBytesToBitBlock(src, size)
+	if err != nil {
+		panic(err.Error())
+	}
+	return bitBlock
+}
+
+// TryBytesToBitBlock is like BytesToBitBlock, but it returns
+// an error instead of panicking if size < 0.
+func TryBytesToBitBlock(src []byte, size int) (*BitBlock, error) {
 	if size < 0 {
-		panic(panicMessageNegativeSize(size))
+		return nil, errors.New(panicMessageNegativeSize(size))
 	}
 	bits := make([]byte, (size + 7) / 8)
 	for i := 0; i < len(bits)-1 && i < len(src); i++ {
@@ -214,7 +377,44 @@ func BytesToBitBlock(src []byte, size int) *BitBlock {
 	return &BitBlock{
 		bits: bits,
 		size: size,
+	}, nil
+}
+
+// clearPaddingBits sets to 0 the padding bits of the last
+// byte of the BitBlock, which could have been set to 1 by an
+// operation applied to whole bytes.
+func (block *BitBlock) clearPaddingBits() {
+	if (block.size & 7) != 0 {
+		block.bits[len(block.bits) - 1] &= FirstBitsSet1Uint8(block.size & 7)
+	}
+}
+
+// RandomBitBlock returns a new BitBlock of the given size with
+// random bits, using r as the source of randomness, so the same
+// BitBlock is obtained from sources with the same seed.
+// RandomBitBlock panics if size < 0.
+func RandomBitBlock(size int, r *rand.Rand) *BitBlock {
+	bitBlock := NewZeroBitBlock(size)
+	r.Read(bitBlock.bits)
+	bitBlock.clearPaddingBits()
+	return bitBlock
+}
+
+// BiasedRandomBitBlock returns a new BitBlock of the given size,
+// in which each bit is set to 1 independently with probability p,
+// using r as the source of randomness. BiasedRandomBitBlock panics
+// if size < 0 or if p < 0 or p > 1.
+func BiasedRandomBitBlock(size int, p float64, r *rand.Rand) *BitBlock {
+	if !(0 <= p && p <= 1) {
+		panic(panicMessageInvalidFractionOutOfRange(p))
+	}
+	bitBlock := NewZeroBitBlock(size)
+	for pos := 0; pos < size; pos++ {
+		if r.Float64() < p {
+			bitBlock.Set1(pos)
+		}
 	}
+	return bitBlock
 }
 
 // Get returns the value of the bit at position pos.
@@ -261,11 +461,77 @@ func (block *BitBlock) Set(pos int, value bool) {
 	}
 }
 
+// GetOK returns the value of the bit at position pos and true,
+// or false and false if pos < 0 or pos >= block.Size(), instead
+// of panicking as Get does.
+func (block *BitBlock) GetOK(pos int) (bool, bool) {
+	if !(0 <= pos && pos < block.size) {
+		return false, false
+	}
+	return block.Get(pos), true
+}
+
+// SetOK sets the bit at position pos as Set does and returns
+// true, or it returns false without modifying the BitBlock if
+// pos < 0 or pos >= block.Size(), instead of panicking.
+func (block *BitBlock) SetOK(pos int, value bool) bool {
+	if !(0 <= pos && pos < block.size) {
+		return false
+	}
+	block.Set(pos, value)
+	return true
+}
+
 // Size returns the number of bits used by the BitBlock.
 func (block *BitBlock) Size() int {
 	return block.size
 }
 
+// Equal returns whether this BitBlock and other have the same
+// size and the same bits.
+func (block *BitBlock) Equal(other *BitBlock) bool {
+	return block.size == other.size && bytes.Equal(block.bits, other.bits)
+}
+
+// Compare compares this BitBlock and other lexicographically,
+// as sequences of bits read from position 0 upward, where a bit
+// set to 0 is less than a bit set to 1. At the first position
+// where the BitBlocks differ, the one with the bit set to 0 is the
+// lesser. If one BitBlock is a prefix of the other, the shorter
+// one is the lesser. Note that this is not the order of the
+// BitBlocks as integers, since position 0 is compared first.
+//
+// The result is -1 if block is less than other, 0 if they are
+// equal and +1 if block is greater than other.
+func (block *BitBlock) Compare(other *BitBlock) int {
+	size := block.size
+	if other.size < size {
+		size = other.size
+	}
+	for pos := 0; pos < size; pos++ {
+		if b1, b2 := block.Get(pos), other.Get(pos); b1 != b2 {
+			if b1 {
+				return 1
+			}
+			return -1
+		}
+	}
+	switch true {
+		case block.size < other.size:
+			return -1
+		case block.size > other.size:
+			return 1
+		default:
+			return 0
+	}
+}
+
+// PopCount returns the number of bits set to 1 in this BitBlock
+// (also known as its Hamming weight).
+func (block *BitBlock) PopCount() int {
+	return countSetBits(block.bits)
+}
+
 // GetSubBlock returns a new BitBlock containing a copy of
 // the bits from position l to position r (including l, but
 // excluding r). This method panics if l and r form an
@@ -274,11 +540,19 @@ func (block *BitBlock) GetSubBlock(l int, r int) *BitBlock {
 	if !(0 <= l && l <= r && r <= block.size) {
 		panic(panicMessageInvalidRangeOverBitBlock(block.size, l, r))
 	}
-	size := r-l
-	bitBlock := NewZeroBitBlock(size)
-	for pos:=0; pos < size; pos++ {
-		bitBlock.Set(pos, block.Get(l + pos))
+	bitBlock := NewZeroBitBlock(r - l)
+	j, shift := l / 8, uint(l & 7)
+	if shift == 0 {
+		copy(bitBlock.bits, block.bits[j:])
+	} else {
+		for i := range bitBlock.bits {
+			bitBlock.bits[i] = block.bits[i + j] >> shift
+			if i + j + 1 < len(block.bits) {
+				bitBlock.bits[i] |= block.bits[i + j + 1] << (8 - shift)
+			}
+		}
 	}
+	bitBlock.clearPaddingBits()
 	return bitBlock
 }
 
@@ -293,6 +567,14 @@ func (block *BitBlock) ToBytes() []byte {
 	return bits
 }
 
+// AppendBytes appends the bytes returned by ToBytes to dst and
+// returns the extended slice, following the same conventions as
+// the built-in append function. It allows reusing a buffer to
+// get the bytes of many BitBlocks without allocating memory.
+func (block *BitBlock) AppendBytes(dst []byte) []byte {
+	return append(dst, block.bits...)
+}
+
 // Clone returns a new BitBlock containing a copy of the
 // bits in this BitBlock.
 func (block *BitBlock) Clone() *BitBlock {
@@ -335,17 +617,25 @@ func (block *BitBlock) RemoveLastBits(k int) *BitBlock {
 	return BytesToBitBlock(block.bits, block.size - k)
 }
 
+// binaryStringOfByte holds, for each byte b, the 8 characters of
+// the binary string of a BitBlock whose bytes are only b, that is,
+// the bits of b from the least significant to the most significant.
+var binaryStringOfByte = func() (table [256][8]byte) {
+	for b := range table {
+		for i := range table[b] {
+			table[b][i] = '0' + byte((b >> i) & 1)
+		}
+	}
+	return table
+}()
+
 // ToBinaryString returns this BitBlock as a binary string.
 func (block *BitBlock) ToBinaryString() string {
-	binChars := make([]byte, block.size)
-	for i := 0; i < block.size; i++ {
-		if block.Get(i) {
-			binChars[i] = '1'
-		} else {
-			binChars[i] = '0'
-		}
+	binChars := make([]byte, 8 * len(block.bits))
+	for i, b := range block.bits {
+		copy(binChars[8 * i:], binaryStringOfByte[b][:])
 	}
-	return string(binChars)
+	return string(binChars[:block.size])
 }
 
 // Concatenate receives multiple BitBlocks and returns a new
@@ -357,12 +647,23 @@ func Concatenate(bitBlocks ...*BitBlock) *BitBlock {
 		size += bitBlock.Size()
 	}
 	concatenatedBitBlock := NewZeroBitBlock(size)
+	bits := concatenatedBitBlock.bits
 	currentSize := 0
 	for _, bitBlock := range bitBlocks {
-		for i := 0; i < bitBlock.Size(); i++ {
-			concatenatedBitBlock.Set(currentSize, bitBlock.Get(i))
-			currentSize++
+		// The bits from currentSize onward are still 0 and the padding
+		// bits of bitBlock are 0, so its bytes can be ORed into place.
+		j, shift := currentSize / 8, uint(currentSize & 7)
+		if shift == 0 {
+			copy(bits[j:], bitBlock.bits)
+		} else {
+			for i, b := range bitBlock.bits {
+				bits[j + i] |= b << shift
+				if j + i + 1 < len(bits) {
+					bits[j + i + 1] |= b >> (8 - shift)
+				}
+			}
 		}
+		currentSize += bitBlock.Size()
 	}
 	return concatenatedBitBlock
 }
@@ -592,4 +893,1550 @@ func BitBlockToUint64(bitBlock *BitBlock) uint64 {
 		x = x | (uint64(bytes[i]) << (8 * i))
 	}
 	return x
-}
\ No newline at end of file
+}
+
+// Uint16ToBitBlockBE converts a 16-bit unsigned integer to a 16-bit BitBlock.
+// The number is stored in big endian format, that is, the most
+// significant byte is stored first (at positions 0 to 7), but the
+// bits of each byte are stored as in BytesToBitBlock.
+func Uint16ToBitBlockBE(x uint16) *BitBlock {
+	return BytesToBitBlock([]byte{byte(x >> 8), byte(x)}, 16)
+}
+
+// Uint32ToBitBlockBE converts a 32-bit unsigned integer to a 32-bit BitBlock.
+// The number is stored in big endian format (see Uint16ToBitBlockBE).
+func Uint32ToBitBlockBE(x uint32) *BitBlock {
+	bits := make([]byte, 4)
+	for i := 0; i < len(bits); i++ {
+		bits[len(bits) - 1 - i] = byte(x >> (8 * i))
+	}
+	return BytesToBitBlock(bits, 32)
+}
+
+// Uint64ToBitBlockBE converts a 64-bit unsigned integer to a 64-bit BitBlock.
+// The number is stored in big endian format (see Uint16ToBitBlockBE).
+func Uint64ToBitBlockBE(x uint64) *BitBlock {
+	bits := make([]byte, 8)
+	for i := 0; i < len(bits); i++ {
+		bits[len(bits) - 1 - i] = byte(x >> (8 * i))
+	}
+	return BytesToBitBlock(bits, 64)
+}
+
+// Int16ToBitBlockBE converts a 16-bit integer to a 16-bit BitBlock.
+// The number is stored in big endian format (see Uint16ToBitBlockBE).
+func Int16ToBitBlockBE(x int16) *BitBlock {
+	return Uint16ToBitBlockBE(uint16(x))
+}
+
+// Int32ToBitBlockBE converts a 32-bit integer to a 32-bit BitBlock.
+// The number is stored in big endian format (see Uint16ToBitBlockBE).
+func Int32ToBitBlockBE(x int32) *BitBlock {
+	return Uint32ToBitBlockBE(uint32(x))
+}
+
+// Int64ToBitBlockBE converts a 64-bit integer to a 64-bit BitBlock.
+// The number is stored in big endian format (see Uint16ToBitBlockBE).
+func Int64ToBitBlockBE(x int64) *BitBlock {
+	return Uint64ToBitBlockBE(uint64(x))
+}
+
+// BitBlockToUint16BE converts a 16-bit BitBlock to a 16-bit unsigned integer.
+// The BitBlock is supposed to be in big endian format (see
+// Uint16ToBitBlockBE). This function panics if the size of the
+// passed BitBlock is different from 16.
+func BitBlockToUint16BE(bitBlock *BitBlock) uint16 {
+	if bitBlock.Size() != 16 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("uint16", bitBlock.Size()))
+	}
+	return (uint16(bitBlock.bits[0]) << 8) | uint16(bitBlock.bits[1])
+}
+
+// BitBlockToUint32BE converts a 32-bit BitBlock to a 32-bit unsigned integer.
+// The BitBlock is supposed to be in big endian format (see
+// Uint16ToBitBlockBE). This function panics if the size of the
+// passed BitBlock is different from 32.
+func BitBlockToUint32BE(bitBlock *BitBlock) uint32 {
+	if bitBlock.Size() != 32 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("uint32", bitBlock.Size()))
+	}
+	var x uint32 = 0
+	for _, b := range bitBlock.bits {
+		x = (x << 8) | uint32(b)
+	}
+	return x
+}
+
+// BitBlockToUint64BE converts a 64-bit BitBlock to a 64-bit unsigned integer.
+// The BitBlock is supposed to be in big endian format (see
+// Uint16ToBitBlockBE). This function panics if the size of the
+// passed BitBlock is different from 64.
+func BitBlockToUint64BE(bitBlock *BitBlock) uint64 {
+	if bitBlock.Size() != 64 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("uint64", bitBlock.Size()))
+	}
+	var x uint64 = 0
+	for _, b := range bitBlock.bits {
+		x = (x << 8) | uint64(b)
+	}
+	return x
+}
+
+// BitBlockToInt16BE converts a 16-bit BitBlock to a 16-bit integer.
+// The BitBlock is supposed to be in big endian format (see
+// Uint16ToBitBlockBE). This function panics if the size of the
+// passed BitBlock is different from 16.
+func BitBlockToInt16BE(bitBlock *BitBlock) int16 {
+	if bitBlock.Size() != 16 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("int16", bitBlock.Size()))
+	}
+	return int16(BitBlockToUint16BE(bitBlock))
+}
+
+// BitBlockToInt32BE converts a 32-bit BitBlock to a 32-bit integer.
+// The BitBlock is supposed to be in big endian format (see
+// Uint16ToBitBlockBE). This function panics if the size of the
+// passed BitBlock is different from 32.
+func BitBlockToInt32BE(bitBlock *BitBlock) int32 {
+	if bitBlock.Size() != 32 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("int32", bitBlock.Size()))
+	}
+	return int32(BitBlockToUint32BE(bitBlock))
+}
+
+// BitBlockToInt64BE converts a 64-bit BitBlock to a 64-bit integer.
+// The BitBlock is supposed to be in big endian format (see
+// Uint16ToBitBlockBE). This function panics if the size of the
+// passed BitBlock is different from 64.
+func BitBlockToInt64BE(bitBlock *BitBlock) int64 {
+	if bitBlock.Size() != 64 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("int64", bitBlock.Size()))
+	}
+	return int64(BitBlockToUint64BE(bitBlock))
+}
+
+// UintToBitBlockN converts the n least significant bits of a 64-bit
+// unsigned integer to an n-bit BitBlock, discarding the other bits.
+// The number is stored in little endian format. This function
+// panics if n < 0 or n > 64.
+func UintToBitBlockN(x uint64, n int) *BitBlock {
+	if !(0 <= n && n <= 64) {
+		panic(panicMessageInvalidValueOutOfRange(0, 64, n))
+	}
+	return BytesToBitBlock(Uint64ToBitBlock(x).bits, n)
+}
+
+// BitBlockToUintN converts a BitBlock with up to 64 bits to a
+// 64-bit unsigned integer, in which the bits beyond the size of
+// the BitBlock are set to 0.
+// The BitBlock is supposed to be in little endian format.
+// This function panics if the size of the passed BitBlock is
+// greater than 64.
+func BitBlockToUintN(bitBlock *BitBlock) uint64 {
+	if bitBlock.Size() > 64 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("uint64", bitBlock.Size()))
+	}
+	var x uint64 = 0
+	for i, b := range bitBlock.bits {
+		x = x | (uint64(b) << (8 * i))
+	}
+	return x
+}
+
+// Float32ToBitBlock converts a 32-bit floating-point number to a
+// 32-bit BitBlock holding its IEEE 754 binary representation (see
+// math.Float32bits), so NaN payloads and the sign of zero are kept.
+// The representation is stored in little endian format.
+func Float32ToBitBlock(f float32) *BitBlock {
+	return Uint32ToBitBlock(math.Float32bits(f))
+}
+
+// Float64ToBitBlock converts a 64-bit floating-point number to a
+// 64-bit BitBlock holding its IEEE 754 binary representation (see
+// math.Float64bits), so NaN payloads and the sign of zero are kept.
+// The representation is stored in little endian format.
+func Float64ToBitBlock(f float64) *BitBlock {
+	return Uint64ToBitBlock(math.Float64bits(f))
+}
+
+// BitBlockToFloat32 converts a 32-bit BitBlock holding the IEEE 754
+// binary representation of a 32-bit floating-point number, in
+// little endian format, to that number (see math.Float32frombits).
+// This function panics if the size of the passed BitBlock is
+// different from 32.
+func BitBlockToFloat32(bitBlock *BitBlock) float32 {
+	if bitBlock.Size() != 32 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("float32", bitBlock.Size()))
+	}
+	return math.Float32frombits(BitBlockToUint32(bitBlock))
+}
+
+// BitBlockToFloat64 converts a 64-bit BitBlock holding the IEEE 754
+// binary representation of a 64-bit floating-point number, in
+// little endian format, to that number (see math.Float64frombits).
+// This function panics if the size of the passed BitBlock is
+// different from 64.
+func BitBlockToFloat64(bitBlock *BitBlock) float64 {
+	if bitBlock.Size() != 64 {
+		panic(panicMessageInvalidBitBlockSizeToConvertToInteger("float64", bitBlock.Size()))
+	}
+	return math.Float64frombits(BitBlockToUint64(bitBlock))
+}
+
+// SetBitIndicesU32 returns the positions of the bits set to 1
+// in this BitBlock, sorted in increasing order, as uint32 values.
+// This method panics if block.Size() - 1 does not fit in an uint32,
+// which can only happen on 64-bit architectures with BitBlocks of
+// more than 2^32 bits.
+func (block *BitBlock) SetBitIndicesU32() []uint32 {
+	if uint64(block.size) > uint64(^uint32(0)) + 1 {
+		panic(panicMessageBitBlockTooLargeForUint32Indices(block.size))
+	}
+	indices := []uint32{}
+	for pos := 0; pos < block.size; pos++ {
+		if block.Get(pos) {
+			indices = append(indices, uint32(pos))
+		}
+	}
+	return indices
+}
+
+// U32IndicesToBitBlock returns a new BitBlock of the given size
+// in which only the bits at the positions in indices are set to 1.
+// The indices do not need to be sorted. U32IndicesToBitBlock panics
+// if size < 0 or if some index is not a valid position for the
+// BitBlock.
+func U32IndicesToBitBlock(indices []uint32, size int) *BitBlock {
+	bitBlock := NewZeroBitBlock(size)
+	for _, index := range indices {
+		if uint64(index) >= uint64(size) {
+			panic(panicMessageInvalidIndexOverBitBlock(size, int(index)))
+		}
+		bitBlock.Set1(int(index))
+	}
+	return bitBlock
+}
+
+// CommonPrefix returns a new BitBlock containing the longest
+// sequence of bits that is a prefix of all the passed BitBlocks.
+// If the BitBlocks have different sizes, the prefix is at most
+// as long as the shortest of them. If no BitBlocks are passed,
+// or any of them is empty, an empty BitBlock is returned.
+//
+// The prefix can be stripped from each BitBlock by calling
+// RemoveFirstBits(prefix.Size()).
+func CommonPrefix(blocks []*BitBlock) *BitBlock {
+	if len(blocks) == 0 {
+		return NewZeroBitBlock(0)
+	}
+	size := blocks[0].Size()
+	for _, bitBlock := range blocks {
+		if bitBlock.Size() < size {
+			size = bitBlock.Size()
+		}
+	}
+	for pos := 0; pos < size; pos++ {
+		for _, bitBlock := range blocks {
+			if bitBlock.Get(pos) != blocks[0].Get(pos) {
+				return blocks[0].GetSubBlock(0, pos)
+			}
+		}
+	}
+	return blocks[0].GetSubBlock(0, size)
+}
+
+// OrReduce returns the OR of all the bits in this BitBlock, that
+// is, whether at least one bit is set to 1. An empty BitBlock
+// returns false.
+func (block *BitBlock) OrReduce() bool {
+	for _, b := range block.bits {
+		if b != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// AndReduce returns the AND of all the bits in this BitBlock, that
+// is, whether all the bits are set to 1. The padding bits are not
+// taken into account. An empty BitBlock returns true.
+func (block *BitBlock) AndReduce() bool {
+	n := block.size / 8
+	for i := 0; i < n; i++ {
+		if block.bits[i] != 0xFF {
+			return false
+		}
+	}
+	if (block.size & 7) != 0 {
+		mask := FirstBitsSet1Uint8(block.size & 7)
+		return block.bits[n] == mask
+	}
+	return true
+}
+
+// BitsToToggleForDensity returns how many bits of this BitBlock
+// would have to be toggled so that the fraction of bits set to 1
+// becomes target. The number of bits set to 1 that is aimed for
+// is target * block.Size(), rounded to the nearest integer.
+//
+// If more bits set to 1 are needed, toggleOn is the number of
+// bits set to 0 that should be set to 1, and toggleOff is 0;
+// otherwise toggleOff is the number of bits set to 1 that should
+// be set to 0, and toggleOn is 0. The BitBlock is not modified.
+// This method panics if target < 0 or target > 1.
+func (block *BitBlock) BitsToToggleForDensity(target float64) (toggleOn int, toggleOff int) {
+	if !(0 <= target && target <= 1) {
+		panic(panicMessageInvalidFractionOutOfRange(target))
+	}
+	targetCount := int(math.Round(target * float64(block.size)))
+	count := countSetBits(block.bits)
+	if targetCount >= count {
+		return targetCount - count, 0
+	}
+	return 0, count - targetCount
+}
+
+// CenterOfMass returns the average of the positions of the bits
+// set to 1 in this BitBlock, that is, the sum of those positions
+// divided by the number of bits set to 1. If no bit is set to 1,
+// CenterOfMass returns NaN.
+func (block *BitBlock) CenterOfMass() float64 {
+	sum, count := 0.0, 0
+	for pos := 0; pos < block.size; pos++ {
+		if block.Get(pos) {
+			sum += float64(pos)
+			count++
+		}
+	}
+	if count == 0 {
+		return math.NaN()
+	}
+	return sum / float64(count)
+}
+
+// CompactOnes returns a new BitBlock with the same size as this
+// BitBlock, in which the bits set to 1 are moved to the lowest
+// positions, that is, if there are k bits set to 1, the returned
+// BitBlock has the bits at positions 0 to k-1 set to 1 and the
+// rest set to 0.
+func (block *BitBlock) CompactOnes() *BitBlock {
+	count := countSetBits(block.bits)
+	bits := make([]byte, len(block.bits))
+	for i := 0; i < count / 8; i++ {
+		bits[i] = 0xFF
+	}
+	if (count & 7) != 0 {
+		bits[count / 8] = FirstBitsSet1Uint8(count & 7)
+	}
+	return &BitBlock{
+		bits: bits,
+		size: block.size,
+	}
+}
+
+// ZeroStuff returns a new BitBlock containing the bits of this
+// BitBlock, but with a bit set to 0 inserted after every group of
+// every consecutive bits. If the size of the BitBlock is a multiple
+// of every, the last inserted bit is at the end of the returned
+// BitBlock, whose size is block.Size() + block.Size() / every.
+// This method panics if every < 1.
+func (block *BitBlock) ZeroStuff(every int) *BitBlock {
+	if every < 1 {
+		panic(panicMessageNonPositiveValue("every", every))
+	}
+	bitBlock := NewZeroBitBlock(block.size + block.size / every)
+	for pos := 0; pos < block.size; pos++ {
+		if block.Get(pos) {
+			bitBlock.Set1(pos + pos / every)
+		}
+	}
+	return bitBlock
+}
+
+// UnstuffZeros reverses ZeroStuff, returning a new BitBlock
+// containing the bits of this BitBlock except the ones at every
+// (every+1)-th position, which are the bits inserted by ZeroStuff.
+// The size of the returned BitBlock is block.Size() minus
+// block.Size() / (every+1).
+//
+// The returned bool is false if any of the removed bits was set
+// to 1, which means that the BitBlock was not produced by
+// ZeroStuff(every) or that it was corrupted. This method panics
+// if every < 1.
+func (block *BitBlock) UnstuffZeros(every int) (*BitBlock, bool) {
+	if every < 1 {
+		panic(panicMessageNonPositiveValue("every", every))
+	}
+	bitBlock := NewZeroBitBlock(block.size - block.size / (every + 1))
+	ok := true
+	for pos := 0; pos < block.size; pos++ {
+		switch true {
+			case (pos + 1) % (every + 1) == 0:
+				if block.Get(pos) {
+					ok = false
+				}
+			case block.Get(pos):
+				bitBlock.Set1(pos - pos / (every + 1))
+		}
+	}
+	return bitBlock, ok
+}
+
+// SelectZero returns the position of the k-th bit set to 0 in
+// this BitBlock (0-based, so SelectZero(0) is the position of the
+// first bit set to 0). The padding bits are not taken into account.
+// If k < 0 or the BitBlock has less than k+1 bits set to 0,
+// SelectZero returns -1.
+func (block *BitBlock) SelectZero(k int) int {
+	if k < 0 {
+		return -1
+	}
+	for i, b := range block.bits {
+		zeros := 0xFF ^ b
+		if i == len(block.bits) - 1 && (block.size & 7) != 0 {
+			zeros &= FirstBitsSet1Uint8(block.size & 7)
+		}
+		count := bits.OnesCount8(zeros)
+		if k >= count {
+			k -= count
+			continue
+		}
+		for j := 0; j < 8; j++ {
+			if (zeros & (1 << j)) != 0 {
+				if k == 0 {
+					return 8 * i + j
+				}
+				k--
+			}
+		}
+	}
+	return -1
+}
+
+// RankZero returns the number of bits set to 0 in this BitBlock
+// at positions strictly less than pos. This method panics if
+// pos < 0 or pos > block.Size().
+func (block *BitBlock) RankZero(pos int) int {
+	if !(0 <= pos && pos <= block.size) {
+		panic(panicMessageInvalidIndexOverBitBlock(block.size, pos))
+	}
+	count := 0
+	for i := 0; i < pos / 8; i++ {
+		count += bits.OnesCount8(0xFF ^ block.bits[i])
+	}
+	if (pos & 7) != 0 {
+		count += bits.OnesCount8((0xFF ^ block.bits[pos / 8]) & FirstBitsSet1Uint8(pos & 7))
+	}
+	return count
+}
+
+// EqualConstantTime returns whether a and b have the same size
+// and the same bits. If both BitBlocks have the same size, the
+// time taken does not depend on their bits, since all the bytes
+// are compared without returning early, which makes it suitable
+// to compare secret values. BitBlocks with different sizes are
+// reported as different immediately.
+func EqualConstantTime(a *BitBlock, b *BitBlock) bool {
+	if a.size != b.size {
+		return false
+	}
+	var diff byte = 0
+	for i := 0; i < len(a.bits); i++ {
+		diff |= a.bits[i] ^ b.bits[i]
+	}
+	return diff == 0
+}
+
+// Not flips every bit of this BitBlock in place, so the bits set
+// to 0 become 1 and vice versa. The padding bits remain set to 0.
+func (block *BitBlock) Not() {
+	for i := range block.bits {
+		block.bits[i] = 0xFF ^ block.bits[i]
+	}
+	block.clearPaddingBits()
+}
+
+// Complement returns a new BitBlock with every bit of this
+// BitBlock flipped, so the bits set to 0 become 1 and vice versa.
+// Unlike Not, this BitBlock is not modified.
+func (block *BitBlock) Complement() *BitBlock {
+	bitBlock := block.Clone()
+	bitBlock.Not()
+	return bitBlock
+}
+
+// AndNot returns a new BitBlock in which each bit is set to 1
+// only if the bit at the same position is set to 1 in a and set
+// to 0 in b, that is, the bits set to 1 in b are cleared from a
+// (like the &^ operator). AndNot panics if a and b do not have
+// the same size.
+func AndNot(a *BitBlock, b *BitBlock) *BitBlock {
+	if a.size != b.size {
+		panic(panicMessageDifferentBitBlockSizes(a.size, b.size))
+	}
+	bits := make([]byte, len(a.bits))
+	for i := range bits {
+		bits[i] = a.bits[i] &^ b.bits[i]
+	}
+	return &BitBlock{
+		bits: bits,
+		size: a.size,
+	}
+}
+
+// CopyIf copies the bits of src into this BitBlock if condition
+// is true, and leaves it unchanged otherwise. The copy is done in
+// constant time: every byte is processed in both cases, using a
+// mask derived from condition instead of branching on it, so it
+// is suitable for operations on secret values. This method panics
+// if block and src do not have the same size.
+func (block *BitBlock) CopyIf(condition bool, src *BitBlock) {
+	if block.size != src.size {
+		panic(panicMessageDifferentBitBlockSizes(block.size, src.size))
+	}
+	// The mask is 0x00 if condition is false and 0xFF if it is true.
+	var c byte = 0
+	if condition {
+		c = 1
+	}
+	mask := -c
+	for i := range block.bits {
+		block.bits[i] ^= mask & (block.bits[i] ^ src.bits[i])
+	}
+}
+
+// AndWith sets each bit of this BitBlock to the AND of itself and
+// the bit at the same position in other, modifying this BitBlock
+// in place. This method panics if block and other do not have the
+// same size.
+func (block *BitBlock) AndWith(other *BitBlock) {
+	if block.size != other.size {
+		panic(panicMessageDifferentBitBlockSizes(block.size, other.size))
+	}
+	for i := range block.bits {
+		block.bits[i] &= other.bits[i]
+	}
+}
+
+// OrWith sets each bit of this BitBlock to the OR of itself and
+// the bit at the same position in other, modifying this BitBlock
+// in place. This method panics if block and other do not have the
+// same size.
+func (block *BitBlock) OrWith(other *BitBlock) {
+	if block.size != other.size {
+		panic(panicMessageDifferentBitBlockSizes(block.size, other.size))
+	}
+	for i := range block.bits {
+		block.bits[i] |= other.bits[i]
+	}
+}
+
+// XorWith sets each bit of this BitBlock to the XOR of itself and
+// the bit at the same position in other, modifying this BitBlock
+// in place. This method panics if block and other do not have the
+// same size.
+func (block *BitBlock) XorWith(other *BitBlock) {
+	if block.size != other.size {
+		panic(panicMessageDifferentBitBlockSizes(block.size, other.size))
+	}
+	for i := range block.bits {
+		block.bits[i] ^= other.bits[i]
+	}
+}
+
+// ShiftLeft returns a new BitBlock with the same size as this
+// BitBlock, in which the bits are moved k positions toward the
+// higher positions, that is, the bit at position i is moved to
+// position i+k. The k lowest positions are set to 0 and the bits
+// moved beyond block.Size()-1 are discarded, so if k >= block.Size()
+// all the bits are set to 0. This method panics if k < 0.
+func (block *BitBlock) ShiftLeft(k int) *BitBlock {
+	if k < 0 {
+		panic(panicMessageNegativeShift(k))
+	}
+	bitBlock := NewZeroBitBlock(block.size)
+	if k >= block.size {
+		return bitBlock
+	}
+	byteShift, bitShift := k / 8, uint(k & 7)
+	for i := len(bitBlock.bits) - 1; i >= byteShift; i-- {
+		bitBlock.bits[i] = block.bits[i - byteShift] << bitShift
+		if bitShift > 0 && i - byteShift - 1 >= 0 {
+			bitBlock.bits[i] |= block.bits[i - byteShift - 1] >> (8 - bitShift)
+		}
+	}
+	bitBlock.clearPaddingBits()
+	return bitBlock
+}
+
+// ShiftRight returns a new BitBlock with the same size as this
+// BitBlock, in which the bits are moved k positions toward the
+// lower positions, that is, the bit at position i is moved to
+// position i-k. The k highest positions are set to 0 and the bits
+// moved below position 0 are discarded, so if k >= block.Size()
+// all the bits are set to 0. This method panics if k < 0.
+func (block *BitBlock) ShiftRight(k int) *BitBlock {
+	if k < 0 {
+		panic(panicMessageNegativeShift(k))
+	}
+	bitBlock := NewZeroBitBlock(block.size)
+	if k >= block.size {
+		return bitBlock
+	}
+	byteShift, bitShift := k / 8, uint(k & 7)
+	for i := 0; i + byteShift < len(block.bits); i++ {
+		bitBlock.bits[i] = block.bits[i + byteShift] >> bitShift
+		if bitShift > 0 && i + byteShift + 1 < len(block.bits) {
+			bitBlock.bits[i] |= block.bits[i + byteShift + 1] << (8 - bitShift)
+		}
+	}
+	return bitBlock
+}
+
+// PopCountParallel returns the number of bits set to 1 in this
+// BitBlock, splitting the underlying bytes into workers chunks of
+// similar size that are counted concurrently in their own
+// goroutines. It is intended for very large BitBlocks, where the
+// cost of the goroutines is negligible. This method panics if
+// workers < 1.
+func (block *BitBlock) PopCountParallel(workers int) int {
+	if workers < 1 {
+		panic(panicMessageNonPositiveValue("workers", workers))
+	}
+	if workers > len(block.bits) {
+		workers = len(block.bits)
+	}
+	counts := make([]int, workers)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		l := len(block.bits) * w / workers
+		r := len(block.bits) * (w + 1) / workers
+		wg.Add(1)
+		go func(w int, chunk []byte) {
+			defer wg.Done()
+			counts[w] = countSetBits(chunk)
+		}(w, block.bits[l:r])
+	}
+	wg.Wait()
+	count := 0
+	for _, c := range counts {
+		count += c
+	}
+	return count
+}
+
+// CountTrailingZeros returns the number of consecutive bits set
+// to 0 starting from position 0 upward. If all the bits are set
+// to 0, CountTrailingZeros returns block.Size().
+func (block *BitBlock) CountTrailingZeros() int {
+	for i, b := range block.bits {
+		if b != 0 {
+			return 8 * i + bits.TrailingZeros8(b)
+		}
+	}
+	return block.size
+}
+
+// CountLeadingZeros returns the number of consecutive bits set
+// to 0 starting from position block.Size()-1 downward. If all the
+// bits are set to 0, CountLeadingZeros returns block.Size().
+func (block *BitBlock) CountLeadingZeros() int {
+	for i := len(block.bits) - 1; i >= 0; i-- {
+		if b := block.bits[i]; b != 0 {
+			return block.size - (8 * i + 8 - bits.LeadingZeros8(b))
+		}
+	}
+	return block.size
+}
+
+// FindFirstSet returns the lowest position holding a bit set
+// to 1, or -1 if all the bits are set to 0.
+func (block *BitBlock) FindFirstSet() int {
+	for i, b := range block.bits {
+		if b != 0 {
+			return 8 * i + bits.TrailingZeros8(b)
+		}
+	}
+	return -1
+}
+
+// FindFirstUnset returns the lowest position holding a bit set
+// to 0, or -1 if all the bits are set to 1. The padding bits are
+// not taken into account.
+func (block *BitBlock) FindFirstUnset() int {
+	for i, b := range block.bits {
+		if b != 0xFF {
+			if pos := 8 * i + bits.TrailingZeros8(0xFF ^ b); pos < block.size {
+				return pos
+			}
+			return -1
+		}
+	}
+	return -1
+}
+
+// ToUint16Slice returns the bits of this BitBlock packed into a
+// slice of 16-bit words, in which the word at index i holds the
+// bits from position 16*i to position 16*i+15 in little endian
+// format. The size of the returned slice is the minimum necessary
+// to contain block.Size() bits, and the unused bits of the last
+// word are set to 0.
+func (block *BitBlock) ToUint16Slice() []uint16 {
+	words := make([]uint16, (block.size + 15) / 16)
+	for i, b := range block.bits {
+		words[i / 2] |= uint16(b) << (8 * (i & 1))
+	}
+	return words
+}
+
+// Uint16SliceToBitBlock returns a new BitBlock, which will contain
+// a copy of the first size bits of words, where the word at index i
+// holds the bits from position 16*i to position 16*i+15 in little
+// endian format. If words does not have enough bits, the remaining
+// bits will be set to 0. Uint16SliceToBitBlock panics if size < 0.
+func Uint16SliceToBitBlock(words []uint16, size int) *BitBlock {
+	src := make([]byte, 2 * len(words))
+	for i, word := range words {
+		src[2 * i] = byte(word)
+		src[2 * i + 1] = byte(word >> 8)
+	}
+	return BytesToBitBlock(src, size)
+}
+
+// FindNextSet returns the lowest position greater than or equal
+// to from holding a bit set to 1, or -1 if there is none. The set
+// bits can be iterated as follows:
+//
+//     for i := block.FindNextSet(0); i != -1; i = block.FindNextSet(i+1) {
+//         ...
+//     }
+//
+// This method panics if from < 0 or from > block.Size().
+func (block *BitBlock) FindNextSet(from int) int {
+	if !(0 <= from && from <= block.size) {
+		panic(panicMessageInvalidIndexOverBitBlock(block.size, from))
+	}
+	i := from / 8
+	if i < len(block.bits) {
+		if b := block.bits[i] & LastBitsSet1Uint8(8 - (from & 7)); b != 0 {
+			return 8 * i + bits.TrailingZeros8(b)
+		}
+	}
+	for i++; i < len(block.bits); i++ {
+		if b := block.bits[i]; b != 0 {
+			return 8 * i + bits.TrailingZeros8(b)
+		}
+	}
+	return -1
+}
+
+// KeepLastNSetBits returns a new BitBlock containing a copy of
+// the bits in this BitBlock, but keeping set to 1 only the n bits
+// set to 1 with the highest positions; the other bits are set to
+// 0. If the BitBlock has at most n bits set to 1, the returned
+// BitBlock is equal to it. This method panics if n < 0.
+func (block *BitBlock) KeepLastNSetBits(n int) *BitBlock {
+	if n < 0 {
+		panic(panicMessageNegativeValue("n", n))
+	}
+	bitBlock := NewZeroBitBlock(block.size)
+	for pos := block.size - 1; pos >= 0 && n > 0; pos-- {
+		if block.Get(pos) {
+			bitBlock.Set1(pos)
+			n--
+		}
+	}
+	return bitBlock
+}
+
+// OrAtOffset returns a new BitBlock containing the bits of base
+// with the bits of overlay ORed into it starting at position
+// offset, that is, the bit at position i of overlay is ORed with
+// the bit at position offset+i of base. The size of the returned
+// BitBlock is the maximum of base.Size() and offset+overlay.Size(),
+// so it grows if overlay extends beyond the end of base, and the
+// positions not covered by base are taken as 0. OrAtOffset panics
+// if offset < 0.
+func OrAtOffset(base *BitBlock, overlay *BitBlock, offset int) *BitBlock {
+	if offset < 0 {
+		panic(panicMessageNegativeValue("offset", offset))
+	}
+	size := base.size
+	if offset + overlay.size > size {
+		size = offset + overlay.size
+	}
+	bitBlock := BytesToBitBlock(base.bits, size)
+	for i := 0; i < overlay.size; i++ {
+		if overlay.Get(i) {
+			bitBlock.Set1(offset + i)
+		}
+	}
+	return bitBlock
+}
+
+// HammingDistance returns the number of positions at which the
+// bits of a and b are different. HammingDistance panics if a and
+// b do not have the same size.
+func HammingDistance(a *BitBlock, b *BitBlock) int {
+	if a.size != b.size {
+		panic(panicMessageDifferentBitBlockSizes(a.size, b.size))
+	}
+	distance := 0
+	for i := range a.bits {
+		distance += bits.OnesCount8(a.bits[i] ^ b.bits[i])
+	}
+	return distance
+}
+
+// Reverse returns a new BitBlock with the bits of this BitBlock
+// in reverse order, that is, the bit at position i is moved to
+// position block.Size()-1-i.
+func (block *BitBlock) Reverse() *BitBlock {
+	n := len(block.bits)
+	reversed := &BitBlock{
+		bits: make([]byte, n),
+		size: 8 * n,
+	}
+	for i, b := range block.bits {
+		reversed.bits[n - 1 - i] = bits.Reverse8(b)
+	}
+	// The padding bits of this BitBlock are now the lowest bits of
+	// reversed, so they are discarded by shifting the bits.
+	reversed = reversed.ShiftRight(8 * n - block.size)
+	reversed.size = block.size
+	return reversed
+}
+
+// SetBitPositionsOfValue returns, in increasing order, the
+// positions of the bits set to 1 in the unsigned integer stored
+// in this BitBlock in little endian format, that is, the exponents
+// of the powers of two that add up to that integer. Since position
+// 0 is the least significant bit, these are the positions of the
+// bits set to 1 in the BitBlock.
+func (block *BitBlock) SetBitPositionsOfValue() []int {
+	positions := []int{}
+	for pos := block.FindNextSet(0); pos != -1; pos = block.FindNextSet(pos + 1) {
+		positions = append(positions, pos)
+	}
+	return positions
+}
+
+// SetAll sets every bit of this BitBlock to 1, leaving the
+// padding bits set to 0.
+func (block *BitBlock) SetAll() {
+	for i := range block.bits {
+		block.bits[i] = 0xFF
+	}
+	block.clearPaddingBits()
+}
+
+// ClearAll sets every bit of this BitBlock to 0.
+func (block *BitBlock) ClearAll() {
+	for i := range block.bits {
+		block.bits[i] = 0
+	}
+}
+
+// SetRange sets the bits from position l to position r (including
+// l, but excluding r) to 1 or 0 depending on whether value == true
+// or value == false respectively. The bytes fully covered by the
+// range are set at once. This method panics if l and r form an
+// invalid range for this BitBlock.
+func (block *BitBlock) SetRange(l int, r int, value bool) {
+	if !(0 <= l && l <= r && r <= block.size) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, l, r))
+	}
+	var fill byte = 0
+	if value {
+		fill = 0xFF
+	}
+	// setBits sets the bits of the byte at index i selected by mask.
+	setBits := func(i int, mask byte) {
+		block.bits[i] = (block.bits[i] &^ mask) | (fill & mask)
+	}
+	li, ri := l / 8, r / 8
+	if li == ri {
+		if l < r {
+			setBits(li, LastBitsSet1Uint8(8 - (l & 7)) & FirstBitsSet1Uint8(r & 7))
+		}
+		return
+	}
+	setBits(li, LastBitsSet1Uint8(8 - (l & 7)))
+	for i := li + 1; i < ri; i++ {
+		block.bits[i] = fill
+	}
+	if (r & 7) != 0 {
+		setBits(ri, FirstBitsSet1Uint8(r & 7))
+	}
+}
+
+// Implies returns a new BitBlock holding the material implication
+// of a and b at each position, that is, the bit at position i is
+// set to 0 only if it is set to 1 in a and set to 0 in b. Implies
+// panics if a and b do not have the same size.
+func Implies(a *BitBlock, b *BitBlock) *BitBlock {
+	if a.size != b.size {
+		panic(panicMessageDifferentBitBlockSizes(a.size, b.size))
+	}
+	bitBlock := NewZeroBitBlock(a.size)
+	for i := range bitBlock.bits {
+		bitBlock.bits[i] = (0xFF ^ a.bits[i]) | b.bits[i]
+	}
+	bitBlock.clearPaddingBits()
+	return bitBlock
+}
+
+// Entails returns whether every bit set to 1 in a is also set to
+// 1 in b, that is, whether the positions of the bits set to 1 in
+// a are a subset of those in b. This is the same as Implies(a, b)
+// having all its bits set to 1. Entails panics if a and b do not
+// have the same size.
+func Entails(a *BitBlock, b *BitBlock) bool {
+	if a.size != b.size {
+		panic(panicMessageDifferentBitBlockSizes(a.size, b.size))
+	}
+	for i := range a.bits {
+		if (a.bits[i] &^ b.bits[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// XorByte returns a new BitBlock in which every byte of this
+// BitBlock (see ToBytes) is XORed with b, so the bit at position
+// i is flipped if the bit i mod 8 of b is set to 1. The padding
+// bits remain set to 0.
+func (block *BitBlock) XorByte(b byte) *BitBlock {
+	bitBlock := NewZeroBitBlock(block.size)
+	for i := range bitBlock.bits {
+		bitBlock.bits[i] = block.bits[i] ^ b
+	}
+	bitBlock.clearPaddingBits()
+	return bitBlock
+}
+
+// FillBools writes the values of the first bits of this BitBlock
+// into dst, so that dst[i] is the value of the bit at position i,
+// and returns the number of values written, which is the minimum
+// of block.Size() and len(dst). The elements of dst beyond that
+// number are not modified.
+func (block *BitBlock) FillBools(dst []bool) int {
+	n := block.size
+	if len(dst) < n {
+		n = len(dst)
+	}
+	for pos := 0; pos < n; pos++ {
+		dst[pos] = (block.bits[pos >> 3] & (1 << (pos & 7))) != 0
+	}
+	return n
+}
+
+// Repeat returns a new BitBlock of size n*block.Size() holding n
+// copies of this BitBlock, one after the other, that is, the same
+// as Concatenate(block, block, ..., block) with n arguments. The
+// copies are made by doubling the number of bits already written,
+// so only O(log n) copies are needed. If n == 0, the returned
+// BitBlock is empty. This method panics if n < 0.
+func (block *BitBlock) Repeat(n int) *BitBlock {
+	if n < 0 {
+		panic(panicMessageNegativeValue("n", n))
+	}
+	size := n * block.size
+	bitBlock := NewZeroBitBlock(size)
+	if size == 0 {
+		return bitBlock
+	}
+	copy(bitBlock.bits, block.bits)
+	for filled := block.size; filled < size; {
+		k := filled
+		if size - filled < k {
+			k = size - filled
+		}
+		copyBits(bitBlock.bits, filled, bitBlock.bits, 0, k)
+		filled += k
+	}
+	return bitBlock
+}
+
+// A BlockStats holds several statistics about the bits of a
+// BitBlock, as returned by the Stats method.
+type BlockStats struct {
+	// Size is the number of bits (see BitBlock.Size).
+	Size int
+	// PopCount is the number of bits set to 1 (see BitBlock.PopCount).
+	PopCount int
+	// LeadingZeros is the number of consecutive bits set to 0 from
+	// the last position downward (see BitBlock.CountLeadingZeros).
+	LeadingZeros int
+	// TrailingZeros is the number of consecutive bits set to 0 from
+	// position 0 upward (see BitBlock.CountTrailingZeros).
+	TrailingZeros int
+	// LongestRunOnes is the length of the longest sequence of
+	// consecutive bits set to 1.
+	LongestRunOnes int
+	// LongestRunZeros is the length of the longest sequence of
+	// consecutive bits set to 0.
+	LongestRunZeros int
+	// NumTransitions is the number of positions i such that the bits
+	// at positions i and i+1 are different.
+	NumTransitions int
+}
+
+// Stats returns the statistics of this BitBlock described in
+// BlockStats, all of them computed in a single pass over the bits
+// instead of calling the corresponding methods one by one.
+func (block *BitBlock) Stats() BlockStats {
+	stats := BlockStats{ Size: block.size }
+	if block.size == 0 {
+		return stats
+	}
+	first := block.Get(0)
+	current, run := first, 0
+	for pos := 0; pos < block.size; pos++ {
+		value := (block.bits[pos >> 3] & (1 << (pos & 7))) != 0
+		if value != current {
+			if stats.NumTransitions == 0 && !first {
+				stats.TrailingZeros = run
+			}
+			stats.NumTransitions++
+			current, run = value, 0
+		}
+		run++
+		if value {
+			stats.PopCount++
+			if run > stats.LongestRunOnes {
+				stats.LongestRunOnes = run
+			}
+		} else if run > stats.LongestRunZeros {
+			stats.LongestRunZeros = run
+		}
+	}
+	switch true {
+		case stats.NumTransitions == 0 && !first:
+			stats.TrailingZeros, stats.LeadingZeros = run, run
+		case !current:
+			stats.LeadingZeros = run
+	}
+	return stats
+}
+
+// Insert returns a new BitBlock of size block.Size()+other.Size()
+// with the bits of other inserted into a copy of this BitBlock,
+// starting at position pos, so the bits of this BitBlock from
+// position pos onward are moved other.Size() positions toward the
+// higher positions. If pos == block.Size(), other is appended at
+// the end. This method panics if pos < 0 or pos > block.Size().
+func (block *BitBlock) Insert(pos int, other *BitBlock) *BitBlock {
+	if !(0 <= pos && pos <= block.size) {
+		panic(panicMessageInvalidValueOutOfRange(0, block.size, pos))
+	}
+	bitBlock := NewZeroBitBlock(block.size + other.size)
+	copyBits(bitBlock.bits, 0, block.bits, 0, pos)
+	copyBits(bitBlock.bits, pos, other.bits, 0, other.size)
+	copyBits(bitBlock.bits, pos + other.size, block.bits, pos, block.size - pos)
+	return bitBlock
+}
+
+// MergeIndexLists returns a new BitBlock of the given size in which
+// the bits set to 1 are the union of the positions in lists, that
+// is, the bit at position i is set to 1 if i appears in some of
+// the lists, along with the number of indices that were skipped
+// because they are not valid positions (i < 0 or i >= size). Each
+// list is expected to be sorted in increasing order, but repeated
+// indices, inside a list or across lists, are allowed. This
+// function panics if size < 0.
+func MergeIndexLists(lists [][]int, size int) (*BitBlock, int) {
+	bitBlock := NewZeroBitBlock(size)
+	skipped := 0
+	for _, list := range lists {
+		for i, index := range list {
+			if index >= size {
+				// The rest of the list is sorted, so it is out of range too.
+				skipped += len(list) - i
+				break
+			}
+			if index < 0 {
+				skipped++
+				continue
+			}
+			bitBlock.bits[index >> 3] |= 1 << (index & 7)
+		}
+	}
+	return bitBlock, skipped
+}
+
+// DeleteBits returns a new BitBlock of size block.Size()-(r-l)
+// containing a copy of the bits in this BitBlock, but without the
+// bits from position l to position r (including l, but excluding
+// r), that is, the concatenation of GetSubBlock(0, l) and
+// GetSubBlock(r, block.Size()). This method panics if l and r form
+// an invalid range for this BitBlock.
+func (block *BitBlock) DeleteBits(l int, r int) *BitBlock {
+	if !(0 <= l && l <= r && r <= block.size) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, l, r))
+	}
+	bitBlock := NewZeroBitBlock(block.size - (r - l))
+	copyBits(bitBlock.bits, 0, block.bits, 0, l)
+	copyBits(bitBlock.bits, l, block.bits, r, block.size - r)
+	return bitBlock
+}
+
+// NearestSetBit returns the position of the bit set to 1 that is
+// closest to pos, that is, the one minimizing the distance |i-pos|
+// to its position i, or -1 if there are no bits set to 1. If two
+// bits are at the same distance, the lower position is returned.
+// This method panics if pos < 0 or pos >= block.Size().
+func (block *BitBlock) NearestSetBit(pos int) int {
+	if !(0 <= pos && pos < block.size) {
+		panic(panicMessageInvalidIndexOverBitBlock(block.size, pos))
+	}
+	next := block.FindNextSet(pos)
+
+	// The highest position lower than or equal to pos with a bit set
+	// to 1, scanning the bytes backward.
+	prev := -1
+	i := pos / 8
+	if b := block.bits[i] & FirstBitsSet1Uint8((pos & 7) + 1); b != 0 {
+		prev = 8 * i + 7 - bits.LeadingZeros8(b)
+	}
+	for i--; prev == -1 && i >= 0; i-- {
+		if b := block.bits[i]; b != 0 {
+			prev = 8 * i + 7 - bits.LeadingZeros8(b)
+		}
+	}
+
+	switch true {
+		case prev == -1:
+			return next
+		case next == -1 || pos - prev <= next - pos:
+			return prev
+		default:
+			return next
+	}
+}
+
+// AppendBit adds a bit with the given value at the end of this
+// BitBlock, at position block.Size(), increasing its size by 1.
+// The underlying slice of bytes only grows when the new bit does
+// not fit in the padding bits of the last byte, and it grows as
+// with the built-in append function, so appending n bits one by
+// one takes O(n) time.
+func (block *BitBlock) AppendBit(value bool) {
+	if (block.size & 7) == 0 {
+		block.bits = append(block.bits, 0)
+	}
+	if value {
+		block.bits[block.size >> 3] |= 1 << (block.size & 7)
+	}
+	block.size++
+}
+
+// AppendBitBlock adds the bits of other at the end of this
+// BitBlock, increasing its size by other.Size(), so it is the
+// same as replacing this BitBlock with Concatenate(block, other),
+// but reusing its underlying slice of bytes when possible.
+func (block *BitBlock) AppendBitBlock(other *BitBlock) {
+	size := block.size + other.size
+	n := (size + 7) / 8
+	for len(block.bits) < n {
+		block.bits = append(block.bits, 0)
+	}
+	copyBits(block.bits, block.size, other.bits, 0, other.size)
+	block.size = size
+}
+
+// ToProgressSegments downsamples this BitBlock to width cells,
+// which is useful to render a large BitBlock as a fixed-width bar.
+// The bits are split into width consecutive regions of similar
+// size, the region of the cell c being the positions from
+// c*block.Size()/width to (c+1)*block.Size()/width (excluding the
+// latter), and a cell is true if more than half of the bits in its
+// region are set to 1. If block.Size() < width, some regions would
+// be empty, so those cells take the value of the bit at position
+// c*block.Size()/width; if the BitBlock is empty, all the cells are
+// false. This method panics if width < 1.
+func (block *BitBlock) ToProgressSegments(width int) []bool {
+	if width < 1 {
+		panic(panicMessageNonPositiveValue("width", width))
+	}
+	segments := make([]bool, width)
+	if block.size == 0 {
+		return segments
+	}
+	for c := range segments {
+		l, r := c * block.size / width, (c + 1) * block.size / width
+		if l == r {
+			segments[c] = block.Get(l)
+			continue
+		}
+		ones := 0
+		for pos := l; pos < r; pos++ {
+			if block.Get(pos) {
+				ones++
+			}
+		}
+		segments[c] = 2 * ones > r - l
+	}
+	return segments
+}
+
+// Prepend returns a new BitBlock of size other.Size()+block.Size()
+// with the bits of other followed by the bits of this BitBlock,
+// that is, the same as Concatenate(other, block).
+func (block *BitBlock) Prepend(other *BitBlock) *BitBlock {
+	bitBlock := NewZeroBitBlock(other.size + block.size)
+	copy(bitBlock.bits, other.bits)
+	copyBits(bitBlock.bits, other.size, block.bits, 0, block.size)
+	return bitBlock
+}
+
+// AreDisjoint returns whether a and b do not have a bit set to 1
+// at the same position, that is, whether the AND of a and b has
+// all its bits set to 0. It stops at the first byte in which both
+// BitBlocks have a bit set to 1. AreDisjoint panics if a and b do
+// not have the same size.
+func AreDisjoint(a *BitBlock, b *BitBlock) bool {
+	if a.size != b.size {
+		panic(panicMessageDifferentBitBlockSizes(a.size, b.size))
+	}
+	for i := range a.bits {
+		if (a.bits[i] & b.bits[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Resize returns a new BitBlock of size newSize containing a copy
+// of the first bits of this BitBlock. If newSize > block.Size(),
+// the new bits at the highest positions are set to 0, and if
+// newSize < block.Size(), the bits from position newSize onward
+// are discarded. This method panics if newSize < 0.
+func (block *BitBlock) Resize(newSize int) *BitBlock {
+	return BytesToBitBlock(block.bits, newSize)
+}
+
+// ForEach calls fn with each position of this BitBlock and the
+// value of its bit, in increasing order of position. If fn returns
+// false, the iteration stops and fn is not called for the rest of
+// the positions. In Go 1.23 or later, the All method can be used
+// instead with a range loop.
+func (block *BitBlock) ForEach(fn func(index int, value bool) bool) {
+	for pos := 0; pos < block.size; pos++ {
+		if !fn(pos, block.Get(pos)) {
+			return
+		}
+	}
+}
+
+// Splice returns a new BitBlock in which the bits of this BitBlock
+// from position l to position r (including l, but excluding r) are
+// replaced by the bits of replacement, that is, the concatenation
+// of GetSubBlock(0, l), replacement and GetSubBlock(r, block.Size()).
+// Its size is block.Size()-(r-l)+replacement.Size(). This method
+// panics if l and r form an invalid range for this BitBlock.
+func (block *BitBlock) Splice(l int, r int, replacement *BitBlock) *BitBlock {
+	if !(0 <= l && l <= r && r <= block.size) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, l, r))
+	}
+	bitBlock := NewZeroBitBlock(block.size - (r - l) + replacement.size)
+	copyBits(bitBlock.bits, 0, block.bits, 0, l)
+	copyBits(bitBlock.bits, l, replacement.bits, 0, replacement.size)
+	copyBits(bitBlock.bits, l + replacement.size, block.bits, r, block.size - r)
+	return bitBlock
+}
+
+// CopyBits copies n bits of src, starting at position srcPos, into
+// dst, starting at position dstPos, without modifying the other
+// bits of dst. It avoids the intermediate BitBlock that would be
+// allocated by GetSubBlock. Copying between overlapping ranges of
+// the same BitBlock is not supported. CopyBits panics if n < 0 or
+// if the range of n bits starting at srcPos or at dstPos is not a
+// valid range for src or dst respectively.
+func CopyBits(dst *BitBlock, dstPos int, src *BitBlock, srcPos int, n int) {
+	if n < 0 {
+		panic(panicMessageNegativeValue("n", n))
+	}
+	if !(0 <= srcPos && srcPos <= src.size - n) {
+		panic(panicMessageInvalidRangeOverBitBlock(src.size, srcPos, srcPos + n))
+	}
+	if !(0 <= dstPos && dstPos <= dst.size - n) {
+		panic(panicMessageInvalidRangeOverBitBlock(dst.size, dstPos, dstPos + n))
+	}
+	copyBits(dst.bits, dstPos, src.bits, srcPos, n)
+}
+
+// GetByte returns the byte formed by the bits from position
+// 8*byteIndex to position 8*byteIndex+7, in which the bit at
+// position 8*byteIndex+i is the bit i of the byte. This method
+// panics if byteIndex < 0 or 8*byteIndex+8 > block.Size().
+func (block *BitBlock) GetByte(byteIndex int) byte {
+	if !(0 <= byteIndex && byteIndex < block.size / 8) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, 8 * byteIndex, 8 * byteIndex + 8))
+	}
+	return block.bits[byteIndex]
+}
+
+// SetByte sets the bits from position 8*byteIndex to position
+// 8*byteIndex+7 to the bits of value, so that the bit at position
+// 8*byteIndex+i is the bit i of value. This method panics if
+// byteIndex < 0 or 8*byteIndex+8 > block.Size(), so the padding
+// bits are never modified.
+func (block *BitBlock) SetByte(byteIndex int, value byte) {
+	if !(0 <= byteIndex && byteIndex < block.size / 8) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, 8 * byteIndex, 8 * byteIndex + 8))
+	}
+	block.bits[byteIndex] = value
+}
+
+// GetBits returns the n bits starting at position pos as an
+// unsigned integer stored in little endian format, that is, the
+// bit at position pos+i is the bit i of the returned value, and
+// the bits beyond n are 0. This method panics if n < 0, n > 64 or
+// pos+n > block.Size().
+func (block *BitBlock) GetBits(pos int, n int) uint64 {
+	if !(0 <= n && n <= 64) {
+		panic(panicMessageInvalidValueOutOfRange(0, 64, n))
+	}
+	if !(0 <= pos && pos <= block.size - n) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, pos, pos + n))
+	}
+	var buf [8]byte
+	copyBits(buf[:], 0, block.bits, pos, n)
+	var x uint64 = 0
+	for i, b := range buf {
+		x |= uint64(b) << (8 * i)
+	}
+	return x
+}
+
+// PutBits writes the n least significant bits of value into the
+// n bits starting at position pos, so that the bit i of value is
+// written at position pos+i, and the other bits of value are
+// ignored. It is the inverse of GetBits; it is not named SetBits
+// because that is the name of the iterator over the bits set to 1.
+// This method panics if n < 0, n > 64 or pos+n > block.Size().
+func (block *BitBlock) PutBits(pos int, n int, value uint64) {
+	if !(0 <= n && n <= 64) {
+		panic(panicMessageInvalidValueOutOfRange(0, 64, n))
+	}
+	if !(0 <= pos && pos <= block.size - n) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, pos, pos + n))
+	}
+	var buf [8]byte
+	for i := range buf {
+		buf[i] = byte(value >> (8 * i))
+	}
+	copyBits(block.bits, pos, buf[:], 0, n)
+}
+
+// FindLastSet returns the highest position holding a bit set to
+// 1, or -1 if all the bits are set to 0.
+func (block *BitBlock) FindLastSet() int {
+	for i := len(block.bits) - 1; i >= 0; i-- {
+		if b := block.bits[i]; b != 0 {
+			return 8 * i + 7 - bits.LeadingZeros8(b)
+		}
+	}
+	return -1
+}
+
+// FindLastUnset returns the highest position holding a bit set to
+// 0, or -1 if all the bits are set to 1. The padding bits are not
+// taken into account.
+func (block *BitBlock) FindLastUnset() int {
+	for i := len(block.bits) - 1; i >= 0; i-- {
+		b := 0xFF ^ block.bits[i]
+		if i == len(block.bits) - 1 && (block.size & 7) != 0 {
+			b &= FirstBitsSet1Uint8(block.size & 7)
+		}
+		if b != 0 {
+			return 8 * i + 7 - bits.LeadingZeros8(b)
+		}
+	}
+	return -1
+}
+
+// Parity returns whether the number of bits set to 1 in this
+// BitBlock is odd, which is the same as PopCount()%2 == 1 but
+// computed by XORing all the bytes together and then folding the
+// bits of the result. An empty BitBlock has even parity (false).
+func (block *BitBlock) Parity() bool {
+	var x byte = 0
+	for _, b := range block.bits {
+		x ^= b
+	}
+	x ^= x >> 4
+	x ^= x >> 2
+	x ^= x >> 1
+	return (x & 1) == 1
+}
+
+// ToGray returns a new BitBlock with the reflected Gray code of the
+// unsigned integer stored in little endian format in this BitBlock,
+// that is, the integer x XOR (x >> 1). Since the bit at position 0
+// is the least significant bit, the bit at position i of the result
+// is the XOR of the bits at positions i and i+1, and the last bit is
+// kept unchanged.
+func (block *BitBlock) ToGray() *BitBlock {
+	bitBlock := block.ShiftRight(1)
+	for i := range bitBlock.bits {
+		bitBlock.bits[i] ^= block.bits[i]
+	}
+	return bitBlock
+}
+
+// FromGray returns a new BitBlock with the unsigned integer whose
+// reflected Gray code (see ToGray) is stored in this BitBlock, so
+// FromGray is the inverse of ToGray. The bit at position i of the
+// result is the XOR of all the bits from position i onward.
+func (block *BitBlock) FromGray() *BitBlock {
+	bitBlock := NewZeroBitBlock(block.size)
+	var carry byte = 0
+	for i := len(block.bits) - 1; i >= 0; i-- {
+		// The XOR of the bits of x from each position upward within
+		// the byte, flipped if the bits of the higher bytes have odd
+		// parity. The padding bits are 0 and the last byte is never
+		// flipped, so they stay 0.
+		x := block.bits[i]
+		x ^= x >> 1
+		x ^= x >> 2
+		x ^= x >> 4
+		x ^= 0xFF * carry
+		bitBlock.bits[i] = x
+		carry = x & 1
+	}
+	return bitBlock
+}
+
+// HasPrefix returns whether the first prefix.Size() bits of this
+// BitBlock are equal to the bits of prefix. An empty prefix is a
+// prefix of every BitBlock, and a prefix larger than this BitBlock
+// is never a prefix of it.
+func (block *BitBlock) HasPrefix(prefix *BitBlock) bool {
+	if prefix.size > block.size {
+		return false
+	}
+	n := prefix.size / 8
+	if !bytes.Equal(block.bits[:n], prefix.bits[:n]) {
+		return false
+	}
+	if k := prefix.size & 7; k != 0 {
+		return (block.bits[n] & FirstBitsSet1Uint8(k)) == prefix.bits[n]
+	}
+	return true
+}
+
+// HasSuffix returns whether the last suffix.Size() bits of this
+// BitBlock are equal to the bits of suffix. An empty suffix is a
+// suffix of every BitBlock, and a suffix larger than this BitBlock
+// is never a suffix of it.
+func (block *BitBlock) HasSuffix(suffix *BitBlock) bool {
+	if suffix.size > block.size {
+		return false
+	}
+	return block.GetSubBlock(block.size - suffix.size, block.size).Equal(suffix)
+}
+
+// matchesAt returns whether the bits of this BitBlock from
+// position pos onward start with the bits of pattern, assuming
+// that pos+pattern.Size() <= block.Size(). If pos is a multiple
+// of 8, the bytes are compared directly; otherwise the bits are
+// compared 64 at a time.
+func (block *BitBlock) matchesAt(pos int, pattern *BitBlock) bool {
+	if (pos & 7) == 0 {
+		n, j := pattern.size / 8, pos / 8
+		if !bytes.Equal(block.bits[j:j + n], pattern.bits[:n]) {
+			return false
+		}
+		if k := pattern.size & 7; k != 0 {
+			return (block.bits[j + n] & FirstBitsSet1Uint8(k)) == pattern.bits[n]
+		}
+		return true
+	}
+	for k := 0; k < pattern.size; k += 64 {
+		n := 64
+		if pattern.size - k < n {
+			n = pattern.size - k
+		}
+		if block.GetBits(pos + k, n) != pattern.GetBits(k, n) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexFrom returns the lowest position greater than or equal to
+// from at which pattern occurs in this BitBlock, or -1 if there is
+// none, assuming that 0 <= from <= block.Size().
+func (block *BitBlock) indexFrom(pattern *BitBlock, from int) int {
+	for pos := from; pos + pattern.size <= block.size; pos++ {
+		if block.matchesAt(pos, pattern) {
+			return pos
+		}
+	}
+	return -1
+}
+
+// Index returns the lowest position at which pattern occurs in
+// this BitBlock, that is, the lowest position pos such that the
+// bits from position pos to position pos+pattern.Size()-1 are equal
+// to the bits of pattern, or -1 if pattern does not occur. If
+// pattern is empty, Index returns 0.
+func (block *BitBlock) Index(pattern *BitBlock) int {
+	return block.indexFrom(pattern, 0)
+}
+
+// Count returns the number of non-overlapping occurrences of
+// pattern in this BitBlock, found by scanning from position 0
+// upward and resuming the search right after each occurrence, so
+// for example "11" occurs 2 times in "11111". If pattern is empty,
+// Count returns block.Size()+1, the number of positions between
+// the bits, as strings.Count does.
+func (block *BitBlock) Count(pattern *BitBlock) int {
+	if pattern.size == 0 {
+		return block.size + 1
+	}
+	count := 0
+	for pos := block.indexFrom(pattern, 0); pos != -1; pos = block.indexFrom(pattern, pos + pattern.size) {
+		count++
+	}
+	return count
+}
+
+// SliceStride returns a new BitBlock with the bits at positions
+// l, l+step, l+2*step, ... that are lower than r, in that order,
+// so SliceStride(l, r, 1) is the same as GetSubBlock(l, r). The
+// size of the returned BitBlock is the number of those positions.
+// This method panics if l and r form an invalid range for this
+// BitBlock or if step < 1.
+func (block *BitBlock) SliceStride(l int, r int, step int) *BitBlock {
+	if !(0 <= l && l <= r && r <= block.size) {
+		panic(panicMessageInvalidRangeOverBitBlock(block.size, l, r))
+	}
+	if step < 1 {
+		panic(panicMessageNonPositiveValue("step", step))
+	}
+	if step == 1 {
+		return block.GetSubBlock(l, r)
+	}
+	bitBlock := NewZeroBitBlock((r - l + step - 1) / step)
+	for i, pos := 0, l; pos < r; i, pos = i+1, pos+step {
+		if (block.bits[pos >> 3] & (1 << (pos & 7))) != 0 {
+			bitBlock.bits[i >> 3] |= 1 << (i & 7)
+		}
+	}
+	return bitBlock
+}
+
+// Swap exchanges the values of the bits at positions i and j.
+// If i == j, the BitBlock is not modified. This method panics if
+// i or j is not a valid position for this BitBlock.
+func (block *BitBlock) Swap(i int, j int) {
+	vi, vj := block.Get(i), block.Get(j)
+	if vi != vj {
+		block.Set(i, vj)
+		block.Set(j, vi)
+	}
+}
@@ -0,0 +1,305 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"math"
+	"strconv"
+)
+
+
+// LevenshteinDistance returns the edit distance between a and b,
+// that is, the minimum number of insertions, deletions and
+// substitutions of single bits needed to transform the sequence
+// of bits of a into the sequence of bits of b.
+//
+// The distance is computed with the classic dynamic programming
+// algorithm, which takes O(a.Size() * b.Size()) time and
+// O(b.Size()) memory.
+func LevenshteinDistance(a *BitBlock, b *BitBlock) int {
+	prev := make([]int, b.size + 1)
+	curr := make([]int, b.size + 1)
+	for j := 0; j <= b.size; j++ {
+		prev[j] = j
+	}
+	for i := 1; i <= a.size; i++ {
+		curr[0] = i
+		for j := 1; j <= b.size; j++ {
+			cost := 1
+			if a.Get(i-1) == b.Get(j-1) {
+				cost = 0
+			}
+			curr[j] = prev[j-1] + cost
+			if prev[j] + 1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if curr[j-1] + 1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[b.size]
+}
+
+// LCSLength returns the length of the longest common subsequence
+// of a and b, that is, the size of the longest sequence of bits
+// that can be obtained from both a and b by removing some of
+// their bits without changing the order of the remaining ones.
+//
+// The length is computed with the classic dynamic programming
+// algorithm, which takes O(a.Size() * b.Size()) time and
+// O(b.Size()) memory.
+func LCSLength(a *BitBlock, b *BitBlock) int {
+	prev := make([]int, b.size + 1)
+	curr := make([]int, b.size + 1)
+	for i := 1; i <= a.size; i++ {
+		for j := 1; j <= b.size; j++ {
+			switch true {
+				case a.Get(i-1) == b.Get(j-1):
+					curr[j] = prev[j-1] + 1
+				case prev[j] >= curr[j-1]:
+					curr[j] = prev[j]
+				default:
+					curr[j] = curr[j-1]
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return prev[b.size]
+}
+
+// DeBruijnBitBlock returns a binary De Bruijn sequence of the
+// given order as a BitBlock of size 2^order, in which every
+// sequence of order bits appears exactly once as a cyclic
+// sub-block (wrapping around from the end to the beginning).
+//
+// The returned sequence is the lexicographically smallest one,
+// generated by concatenating the binary Lyndon words whose length
+// divides order. DeBruijnBitBlock panics if order < 1 or if 2^order
+// does not fit in an int.
+func DeBruijnBitBlock(order int) *BitBlock {
+	maxOrder := strconv.IntSize - 2
+	if !(1 <= order && order <= maxOrder) {
+		panic(panicMessageInvalidValueOutOfRange(1, maxOrder, order))
+	}
+	bitBlock := NewZeroBitBlock(1 << order)
+	word := make([]bool, order + 1)
+	pos := 0
+	var generate func(t int, p int)
+	generate = func(t int, p int) {
+		if t > order {
+			if order % p == 0 {
+				for i := 1; i <= p; i++ {
+					bitBlock.Set(pos, word[i])
+					pos++
+				}
+			}
+			return
+		}
+		word[t] = word[t-p]
+		generate(t + 1, p)
+		if !word[t-p] {
+			word[t] = true
+			generate(t + 1, t)
+		}
+	}
+	generate(1, 1)
+	return bitBlock
+}
+
+// IsDeBruijn returns whether this BitBlock is a binary De Bruijn
+// sequence of the given order, that is, whether its size is
+// 2^order and every sequence of order bits appears exactly once
+// as a cyclic sub-block (wrapping around from the end to the
+// beginning). If order < 1, IsDeBruijn returns false.
+func (block *BitBlock) IsDeBruijn(order int) bool {
+	if !(1 <= order && order <= strconv.IntSize - 2) || block.size != 1 << order {
+		return false
+	}
+	windows := make(map[uint64]struct{}, block.size)
+	for i := 0; i < block.size; i++ {
+		var window uint64 = 0
+		for j := 0; j < order; j++ {
+			if block.Get((i + j) % block.size) {
+				window |= 1 << j
+			}
+		}
+		if _, ok := windows[window]; ok {
+			return false
+		}
+		windows[window] = struct{}{}
+	}
+	return true
+}
+
+// ConsensusBitBlock returns a new BitBlock in which each bit is
+// the value held by the majority of the passed BitBlocks at that
+// position (ties are resolved as 0), and the fraction of the
+// BitBlocks that agree with that value at each position.
+//
+// ConsensusBitBlock panics if no BitBlocks are passed or if they
+// do not all have the same size.
+func ConsensusBitBlock(blocks []*BitBlock) (consensus *BitBlock, confidence []float64) {
+	if len(blocks) == 0 {
+		panic(panicMessageNoBitBlocks())
+	}
+	size := blocks[0].Size()
+	for _, bitBlock := range blocks {
+		if bitBlock.Size() != size {
+			panic(panicMessageDifferentBitBlockSizes(size, bitBlock.Size()))
+		}
+	}
+	consensus = NewZeroBitBlock(size)
+	confidence = make([]float64, size)
+	for pos := 0; pos < size; pos++ {
+		count := 0
+		for _, bitBlock := range blocks {
+			if bitBlock.Get(pos) {
+				count++
+			}
+		}
+		if 2 * count > len(blocks) {
+			consensus.Set1(pos)
+		} else {
+			count = len(blocks) - count
+		}
+		confidence[pos] = float64(count) / float64(len(blocks))
+	}
+	return consensus, confidence
+}
+
+// minHash returns the hash of the position pos used to build
+// MinHash signatures, which is the SplitMix64 finalizer applied
+// to pos combined with seed, truncated to 32 bits.
+func minHash(seed uint64, pos int) uint32 {
+	x := seed + 0x9E3779B97F4A7C15 * (uint64(pos) + 1)
+	x = (x ^ (x >> 30)) * 0xBF58476D1CE4E5B9
+	x = (x ^ (x >> 27)) * 0x94D049BB133111EB
+	x ^= x >> 31
+	return uint32(x >> 32)
+}
+
+// MinHashSignature returns the MinHash signature of this BitBlock,
+// seen as the set of the positions of its bits set to 1. For each
+// seed, the signature holds the minimum hash over those positions,
+// using a hash function chosen by the seed. If no bit is set to 1,
+// every value of the signature is the maximum uint32.
+//
+// The fraction of values in which the signatures of two BitBlocks
+// (built with the same seeds) agree is an estimate of the Jaccard
+// similarity of their sets of positions.
+func (block *BitBlock) MinHashSignature(seeds []uint64) []uint32 {
+	signature := make([]uint32, len(seeds))
+	for i := range signature {
+		signature[i] = ^uint32(0)
+	}
+	for pos := 0; pos < block.size; pos++ {
+		if !block.Get(pos) {
+			continue
+		}
+		for i, seed := range seeds {
+			if h := minHash(seed, pos); h < signature[i] {
+				signature[i] = h
+			}
+		}
+	}
+	return signature
+}
+
+// EstimateJaccard returns the fraction of positions in which the
+// MinHash signatures sigA and sigB have the same value, which is
+// an estimate of the Jaccard similarity of the BitBlocks they were
+// computed from (see MinHashSignature). The estimate is more
+// accurate the longer the signatures are. If the signatures are
+// empty, EstimateJaccard returns NaN. This function panics if the
+// signatures do not have the same length.
+func EstimateJaccard(sigA []uint32, sigB []uint32) float64 {
+	if len(sigA) != len(sigB) {
+		panic(panicMessageDifferentSignatureLengths(len(sigA), len(sigB)))
+	}
+	if len(sigA) == 0 {
+		return math.NaN()
+	}
+	agree := 0
+	for i := range sigA {
+		if sigA[i] == sigB[i] {
+			agree++
+		}
+	}
+	return float64(agree) / float64(len(sigA))
+}
+
+// IsArithmeticProgression returns whether the positions of the
+// bits set to 1 in this BitBlock form an arithmetic progression,
+// that is, whether they are start, start+step, start+2*step, ...
+// for some start and some step > 0, which are also returned.
+//
+// If only one bit is set to 1, the positions form a progression
+// with step 0 starting at that position. If no bit is set to 1,
+// IsArithmeticProgression returns false, -1 and 0. If the positions
+// do not form a progression, start and step are the ones given by
+// the first two positions.
+func (block *BitBlock) IsArithmeticProgression() (ok bool, start int, step int) {
+	start = block.FindFirstSet()
+	if start == -1 {
+		return false, -1, 0
+	}
+	next := block.FindNextSet(start + 1)
+	if next == -1 {
+		return true, start, 0
+	}
+	step = next - start
+	for expected := next + step; next != -1; expected += step {
+		next = block.FindNextSet(next + 1)
+		if next != -1 && next != expected {
+			return false, start, step
+		}
+	}
+	return true, start, step
+}
+
+// RunSpectrum returns the histograms of the lengths of the runs
+// of this BitBlock, that is, of the maximal sequences of
+// consecutive bits with the same value. ones maps each length to
+// the number of runs of bits set to 1 with that length, and zeros
+// does the same for the runs of bits set to 0. Lengths without
+// runs are not present in the maps.
+func (block *BitBlock) RunSpectrum() (ones map[int]int, zeros map[int]int) {
+	ones, zeros = map[int]int{}, map[int]int{}
+	for pos := 0; pos < block.size; {
+		value, length := block.Get(pos), 0
+		for ; pos < block.size && block.Get(pos) == value; pos++ {
+			length++
+		}
+		if value {
+			ones[length]++
+		} else {
+			zeros[length]++
+		}
+	}
+	return ones, zeros
+}
+
+// CyclicAutocorrelation returns a slice of size block.Size(), in
+// which the value at index k is the number of positions i such
+// that the bit at position i is equal to the bit at position
+// (i+k) mod block.Size(), that is, the number of bits that agree
+// between the BitBlock and its rotation by k positions. The value
+// at index 0 is always block.Size().
+func (block *BitBlock) CyclicAutocorrelation() []int {
+	correlation := make([]int, block.size)
+	values := make([]bool, block.size)
+	for i := range values {
+		values[i] = block.Get(i)
+	}
+	for k := 0; k < block.size; k++ {
+		for i := 0; i < block.size; i++ {
+			if values[i] == values[(i + k) % block.size] {
+				correlation[k]++
+			}
+		}
+	}
+	return correlation
+}
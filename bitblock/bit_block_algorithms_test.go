@@ -0,0 +1,439 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+
+// Test the LevenshteinDistance() function.
+func TestLevenshteinDistance(t *testing.T) {
+	type Test struct { id string; a string; b string; distance int }
+
+	// Test cases.
+	tests := []Test{
+		// Only substitutions, the distance equals the number of
+		// positions where the bits differ.
+		Test{ id: "0000", a: "10110", b: "10110", distance: 0 },
+		Test{ id: "0001", a: "10110", b: "10011", distance: 2 },
+		Test{ id: "0002", a: "1111", b: "0000", distance: 4 },
+		// An insertion or deletion is cheaper than the substitutions.
+		Test{ id: "0003", a: "01010101", b: "10101010", distance: 2 },
+		Test{ id: "0004", a: "1011", b: "10011", distance: 1 },
+		Test{ id: "0005", a: "110010", b: "10010", distance: 1 },
+		// Empty BitBlocks.
+		Test{ id: "0006", a: "", b: "10110", distance: 5 },
+		Test{ id: "0007", a: "011", b: "", distance: 3 },
+		Test{ id: "0008", a: "", b: "", distance: 0 },
+	}
+
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			if d := LevenshteinDistance(a, b); d != test.distance {
+				t.Fatalf("got LevenshteinDistance(%q, %q) = %d, want %d", test.a, test.b, d, test.distance)
+			}
+			if d := LevenshteinDistance(b, a); d != test.distance {
+				t.Fatalf("got LevenshteinDistance(%q, %q) = %d, want %d", test.b, test.a, d, test.distance)
+			}
+		})
+	}
+}
+
+// Test the LCSLength() function.
+func TestLCSLength(t *testing.T) {
+	type Test struct { id string; a string; b string; length int }
+
+	// Test cases.
+	tests := []Test{
+		// One BitBlock is a subsequence of the other.
+		Test{ id: "0000", a: "101", b: "1001101", length: 3 },
+		Test{ id: "0001", a: "0110", b: "0110", length: 4 },
+		Test{ id: "0002", a: "", b: "0110", length: 0 },
+		// BitBlocks with disjoint patterns.
+		Test{ id: "0003", a: "1111", b: "0000", length: 0 },
+		Test{ id: "0004", a: "111000", b: "000111", length: 3 },
+		Test{ id: "0005", a: "0101010", b: "1010101", length: 6 },
+		Test{ id: "0006", a: "110100", b: "011011", length: 4 },
+	}
+
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			if length := LCSLength(a, b); length != test.length {
+				t.Fatalf("got LCSLength(%q, %q) = %d, want %d", test.a, test.b, length, test.length)
+			}
+			if length := LCSLength(b, a); length != test.length {
+				t.Fatalf("got LCSLength(%q, %q) = %d, want %d", test.b, test.a, length, test.length)
+			}
+		})
+	}
+}
+
+// Test the DeBruijnBitBlock() function.
+func TestDeBruijnBitBlock(t *testing.T) {
+	// Test that the sequence of order 3 is the expected one.
+	if ok := checkBitBlockBinaryString(t, DeBruijnBitBlock(3), "00010111"); !ok {
+		t.Fatalf("wrong answer for DeBruijnBitBlock(3)")
+	}
+
+	// Test that every sequence of order bits appears exactly once as a
+	// cyclic sub-block.
+	for order := 1; order <= 12; order++ {
+		bitBlock := DeBruijnBitBlock(order)
+		if !checkBitBlockSize(t, bitBlock, 1 << order) {
+			t.Fatalf("wrong size for DeBruijnBitBlock(%d)", order)
+		}
+		seen := make([]bool, 1 << order)
+		for i := 0; i < bitBlock.Size(); i++ {
+			window := 0
+			for j := 0; j < order; j++ {
+				if bitBlock.Get((i + j) % bitBlock.Size()) {
+					window |= 1 << j
+				}
+			}
+			if seen[window] {
+				t.Fatalf("the window %d appears more than once in DeBruijnBitBlock(%d)", window, order)
+			}
+			seen[window] = true
+		}
+	}
+
+	// Test that DeBruijnBitBlock() panics for invalid orders.
+	for _, order := range []int{0, -1, -5, 64, 100} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to DeBruijnBitBlock(%d) did not panic", order)
+				}
+			}()
+			DeBruijnBitBlock(order)
+		}()
+	}
+}
+
+// Test the IsDeBruijn() method.
+func TestIsDeBruijn(t *testing.T) {
+	// Test that the sequences returned by DeBruijnBitBlock() are valid,
+	// but only for its order.
+	for order := 1; order <= 12; order++ {
+		bitBlock := DeBruijnBitBlock(order)
+		if !bitBlock.IsDeBruijn(order) {
+			t.Fatalf("got IsDeBruijn(%d) = false for DeBruijnBitBlock(%d), want true", order, order)
+		}
+		for _, order2 := range []int{order - 1, order + 1, -order} {
+			if bitBlock.IsDeBruijn(order2) {
+				t.Fatalf("got IsDeBruijn(%d) = true for DeBruijnBitBlock(%d), want false", order2, order)
+			}
+		}
+	}
+
+	// Test sequences given as binary strings, including near-misses with
+	// the correct size but some repeated window.
+	type Test struct { id string; s string; order int; valid bool }
+	tests := []Test{
+		Test{ id: "0000", s: "00010111", order: 3, valid: true },
+		Test{ id: "0001", s: "11101000", order: 3, valid: true },
+		Test{ id: "0002", s: "01110100", order: 3, valid: true },
+		Test{ id: "0003", s: "00011011", order: 3, valid: false },
+		Test{ id: "0004", s: "00001111", order: 3, valid: false },
+		Test{ id: "0005", s: "0011", order: 2, valid: true },
+		Test{ id: "0006", s: "0101", order: 2, valid: false },
+		Test{ id: "0007", s: "0001011", order: 3, valid: false },
+		Test{ id: "0008", s: "", order: 0, valid: false },
+	}
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			if valid := bitBlock.IsDeBruijn(test.order); valid != test.valid {
+				t.Fatalf("got IsDeBruijn(%d) = %t for %q, want %t", test.order, valid, test.s, test.valid)
+			}
+		})
+	}
+}
+
+// Test the ConsensusBitBlock() function.
+func TestConsensusBitBlock(t *testing.T) {
+	type Test struct { id string; blocks []string; consensus string; confidence []float64 }
+
+	// Test cases.
+	tests := []Test{
+		Test{
+			id: "0000",
+			blocks: []string{"110010", "100011", "111000"},
+			consensus: "110010",
+			confidence: []float64{1, 2.0 / 3.0, 2.0 / 3.0, 1, 2.0 / 3.0, 2.0 / 3.0},
+		},
+		Test{
+			id: "0001",
+			blocks: []string{"10", "01", "11", "00"},
+			consensus: "00",
+			confidence: []float64{0.5, 0.5},
+		},
+		Test{
+			id: "0002",
+			blocks: []string{"1011"},
+			consensus: "1011",
+			confidence: []float64{1, 1, 1, 1},
+		},
+		Test{
+			id: "0003",
+			blocks: []string{"", ""},
+			consensus: "",
+			confidence: []float64{},
+		},
+	}
+
+	for _, test := range tests {
+		blocks := make([]*BitBlock, len(test.blocks))
+		for i, s := range test.blocks {
+			blocks[i] = binaryStringToBitBlock(s)
+		}
+		t.Run(test.id, func(t *testing.T) {
+			consensus, confidence := ConsensusBitBlock(blocks)
+			if ok := checkBitBlockBinaryString(t, consensus, test.consensus); !ok {
+				t.Fatalf("wrong consensus for %v, want %q", test.blocks, test.consensus)
+			}
+			if len(confidence) != len(test.confidence) {
+				t.Fatalf("got len(confidence) = %d, want len(confidence) = %d", len(confidence), len(test.confidence))
+			}
+			for i := range confidence {
+				if math.Abs(confidence[i] - test.confidence[i]) > 1e-9 {
+					t.Fatalf("got confidence[%d] = %g, want confidence[%d] = %g", i, confidence[i], i, test.confidence[i])
+				}
+			}
+		})
+	}
+
+	// Test that ConsensusBitBlock() panics if there are no BitBlocks or
+	// they have different sizes.
+	for _, blocks := range [][]*BitBlock{ {}, {NewZeroBitBlock(3), NewZeroBitBlock(4)} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to ConsensusBitBlock() on %d BitBlocks did not panic", len(blocks))
+				}
+			}()
+			ConsensusBitBlock(blocks)
+		}()
+	}
+}
+
+// Test the MinHashSignature() method.
+func TestMinHashSignature(t *testing.T) {
+	seeds := make([]uint64, 1000)
+	for i := range seeds {
+		seeds[i] = uint64(i) * 7919 + 17
+	}
+
+	// Test that an empty set gives the sentinel value for every seed.
+	for _, bitBlock := range []*BitBlock{NewZeroBitBlock(0), NewZeroBitBlock(100)} {
+		for i, h := range bitBlock.MinHashSignature(seeds) {
+			if h != ^uint32(0) {
+				t.Fatalf("got signature[%d] = %d for a BitBlock of size %d without bits set to 1, want %d", i, h, bitBlock.Size(), ^uint32(0))
+			}
+		}
+	}
+
+	// Test that the fraction of values in which the signatures of two
+	// BitBlocks agree is close to their Jaccard similarity.
+	type Test struct { id string; onlyA int; onlyB int; both int }
+	tests := []Test{
+		Test{ id: "0000", onlyA: 0, onlyB: 0, both: 200 },
+		Test{ id: "0001", onlyA: 50, onlyB: 50, both: 100 },
+		Test{ id: "0002", onlyA: 150, onlyB: 30, both: 20 },
+		Test{ id: "0003", onlyA: 100, onlyB: 100, both: 0 },
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			size := test.onlyA + test.onlyB + test.both + 50
+			a, b := NewZeroBitBlock(size), NewZeroBitBlock(size)
+			pos := 0
+			for i := 0; i < test.onlyA; i, pos = i+1, pos+1 {
+				a.Set1(pos)
+			}
+			for i := 0; i < test.onlyB; i, pos = i+1, pos+1 {
+				b.Set1(pos)
+			}
+			for i := 0; i < test.both; i, pos = i+1, pos+1 {
+				a.Set1(pos)
+				b.Set1(pos)
+			}
+			jaccard := float64(test.both) / float64(test.onlyA + test.onlyB + test.both)
+
+			sigA, sigB := a.MinHashSignature(seeds), b.MinHashSignature(seeds)
+			agree := 0
+			for i := range sigA {
+				if sigA[i] == sigB[i] {
+					agree++
+				}
+			}
+			if estimate := float64(agree) / float64(len(seeds)); math.Abs(estimate - jaccard) > 0.06 {
+				t.Fatalf("the signatures agree in a fraction %g of the values, want a fraction close to the Jaccard similarity %g", estimate, jaccard)
+			}
+		})
+	}
+}
+
+// Test the EstimateJaccard() function.
+func TestEstimateJaccard(t *testing.T) {
+	// Two BitBlocks with a Jaccard similarity of 0.3.
+	a, b := NewZeroBitBlock(1000), NewZeroBitBlock(1000)
+	for pos := 0; pos < 700; pos++ {
+		a.Set1(pos)
+	}
+	for pos := 400; pos < 1000; pos++ {
+		b.Set1(pos)
+	}
+	jaccard := 0.3
+
+	// Test that the estimate gets closer to the Jaccard similarity as
+	// the number of seeds grows.
+	type Test struct { seeds int; tolerance float64 }
+	for _, test := range []Test{ {100, 0.15}, {1000, 0.05}, {10000, 0.015} } {
+		seeds := make([]uint64, test.seeds)
+		for i := range seeds {
+			seeds[i] = uint64(i) * 104729 + 3
+		}
+		estimate := EstimateJaccard(a.MinHashSignature(seeds), b.MinHashSignature(seeds))
+		if math.Abs(estimate - jaccard) > test.tolerance {
+			t.Fatalf("got EstimateJaccard() = %g with %d seeds, want a value within %g of %g", estimate, test.seeds, test.tolerance, jaccard)
+		}
+	}
+
+	// Test that empty signatures give NaN.
+	if estimate := EstimateJaccard([]uint32{}, []uint32{}); !math.IsNaN(estimate) {
+		t.Fatalf("got EstimateJaccard() = %g for empty signatures, want NaN", estimate)
+	}
+
+	// Test that EstimateJaccard() panics if the lengths are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to EstimateJaccard() on signatures of lengths 2 and 3 did not panic")
+			}
+		}()
+		EstimateJaccard([]uint32{1, 2}, []uint32{1, 2, 3})
+	}()
+}
+
+// Test the IsArithmeticProgression() method.
+func TestIsArithmeticProgression(t *testing.T) {
+	type Test struct { id string; s string; ok bool; start int; step int }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "", ok: false, start: -1, step: 0 },
+		Test{ id: "0001", s: "0000000000", ok: false, start: -1, step: 0 },
+		Test{ id: "0002", s: "0000100000", ok: true, start: 4, step: 0 },
+		Test{ id: "0003", s: "0010010010010010010", ok: true, start: 2, step: 3 },
+		Test{ id: "0004", s: "1111111111", ok: true, start: 0, step: 1 },
+		Test{ id: "0005", s: "0100000000000000000001", ok: true, start: 1, step: 20 },
+		Test{ id: "0006", s: "0010010010011010010", ok: false, start: 2, step: 3 },
+		Test{ id: "0007", s: "0010010010000010010", ok: false, start: 2, step: 3 },
+		Test{ id: "0008", s: "1101001000101", ok: false, start: 0, step: 1 },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			ok, start, step := bitBlock.IsArithmeticProgression()
+			if ok != test.ok || start != test.start || step != test.step {
+				t.Fatalf("got IsArithmeticProgression() = (%t, %d, %d) for %q, want (%t, %d, %d)", ok, start, step, test.s, test.ok, test.start, test.step)
+			}
+		})
+	}
+
+	// Test strided BitBlocks with many sizes, starts and steps.
+	for _, size := range []int{1, 8, 9, 64, 135} {
+		for start := 0; start < size; start += 5 {
+			for step := 1; step < 20; step++ {
+				bitBlock := NewZeroBitBlock(size)
+				for pos := start; pos < size; pos += step {
+					bitBlock.Set1(pos)
+				}
+				wantStep := step
+				if start + step >= size {
+					wantStep = 0
+				}
+				ok, start2, step2 := bitBlock.IsArithmeticProgression()
+				if !ok || start2 != start || step2 != wantStep {
+					t.Fatalf("got IsArithmeticProgression() = (%t, %d, %d) for a BitBlock of size %d with bits set every %d positions from %d, want (true, %d, %d)", ok, start2, step2, size, step, start, start, wantStep)
+				}
+			}
+		}
+	}
+}
+
+// Test the RunSpectrum() method.
+func TestRunSpectrum(t *testing.T) {
+	type Test struct { id string; s string; ones map[int]int; zeros map[int]int }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "", ones: map[int]int{}, zeros: map[int]int{} },
+		Test{ id: "0001", s: "0000", ones: map[int]int{}, zeros: map[int]int{4: 1} },
+		Test{ id: "0002", s: "1", ones: map[int]int{1: 1}, zeros: map[int]int{} },
+		Test{ id: "0003", s: "1101000111101010110000011111", ones: map[int]int{1: 3, 2: 2, 4: 1, 5: 1}, zeros: map[int]int{1: 4, 3: 1, 5: 1} },
+		Test{ id: "0004", s: "0101010101", ones: map[int]int{1: 5}, zeros: map[int]int{1: 5} },
+		Test{ id: "0005", s: "000000000111111111111", ones: map[int]int{12: 1}, zeros: map[int]int{9: 1} },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			ones, zeros := bitBlock.RunSpectrum()
+			if !reflect.DeepEqual(ones, test.ones) {
+				t.Fatalf("got ones = %v in RunSpectrum() for %q, want %v", ones, test.s, test.ones)
+			}
+			if !reflect.DeepEqual(zeros, test.zeros) {
+				t.Fatalf("got zeros = %v in RunSpectrum() for %q, want %v", zeros, test.s, test.zeros)
+			}
+		})
+	}
+}
+
+// Test the CyclicAutocorrelation() method.
+func TestCyclicAutocorrelation(t *testing.T) {
+	type Test struct { id string; s string; correlation []int }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "", correlation: []int{} },
+		Test{ id: "0001", s: "1", correlation: []int{1} },
+		Test{ id: "0002", s: "10", correlation: []int{2, 0} },
+		Test{ id: "0003", s: "1100", correlation: []int{4, 2, 0, 2} },
+		Test{ id: "0004", s: "1101000", correlation: []int{7, 3, 3, 3, 3, 3, 3} },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			if correlation := bitBlock.CyclicAutocorrelation(); !reflect.DeepEqual(correlation, test.correlation) {
+				t.Fatalf("got CyclicAutocorrelation() = %v for %q, want %v", correlation, test.s, test.correlation)
+			}
+		})
+	}
+
+	// Test that a periodic BitBlock has its peaks at the multiples of the
+	// period, where the rotation is equal to the BitBlock.
+	period := binaryStringToBitBlock("1101001")
+	bitBlock := Concatenate(period, period, period, period, period)
+	correlation := bitBlock.CyclicAutocorrelation()
+	for k := range correlation {
+		if k % period.Size() == 0 {
+			if correlation[k] != bitBlock.Size() {
+				t.Fatalf("got CyclicAutocorrelation()[%d] = %d for a periodic BitBlock with period %d, want %d", k, correlation[k], period.Size(), bitBlock.Size())
+			}
+		} else if correlation[k] >= bitBlock.Size() {
+			t.Fatalf("got CyclicAutocorrelation()[%d] = %d for a periodic BitBlock with period %d, want a value less than %d", k, correlation[k], period.Size(), bitBlock.Size())
+		}
+	}
+}
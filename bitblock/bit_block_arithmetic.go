@@ -0,0 +1,135 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"math/bits"
+)
+
+
+// CarrylessMultiply returns the carry-less product of a and b,
+// that is, the product of both BitBlocks seen as polynomials over
+// GF(2), where the bit at position i is the coefficient of x^i.
+//
+// The bit at position i of the returned BitBlock is the XOR of
+// a.Get(j) && b.Get(i-j) over all the valid positions j. The size
+// of the returned BitBlock is a.Size() + b.Size() - 1, or 0 if
+// either a or b is empty.
+func CarrylessMultiply(a *BitBlock, b *BitBlock) *BitBlock {
+	if a.size == 0 || b.size == 0 {
+		return NewZeroBitBlock(0)
+	}
+	product := NewZeroBitBlock(a.size + b.size - 1)
+	for j := 0; j < a.size; j++ {
+		if !a.Get(j) {
+			continue
+		}
+		for k := 0; k < b.size; k++ {
+			if b.Get(k) {
+				product.Set(j + k, !product.Get(j + k))
+			}
+		}
+	}
+	return product
+}
+
+// AddWithCarry returns the sum of a, b and carryIn, where a and b
+// are seen as unsigned integers stored in little endian format
+// (the bit at position 0 is the least significant bit). The sum
+// has the same size as a and b, wrapping around on overflow, and
+// carryOut reports whether the addition overflowed that size.
+//
+// Since carryOut can be passed as carryIn to the next addition,
+// integers larger than a BitBlock can be added by chaining the
+// carries of several BitBlocks. AddWithCarry panics if a and b
+// do not have the same size.
+func AddWithCarry(a *BitBlock, b *BitBlock, carryIn bool) (sum *BitBlock, carryOut bool) {
+	if a.size != b.size {
+		panic(panicMessageDifferentBitBlockSizes(a.size, b.size))
+	}
+	if a.size == 0 {
+		return NewZeroBitBlock(0), carryIn
+	}
+	var carry uint16 = 0
+	if carryIn {
+		carry = 1
+	}
+	bits := make([]byte, len(a.bits))
+	for i := range bits {
+		s := uint16(a.bits[i]) + uint16(b.bits[i]) + carry
+		bits[i] = byte(s)
+		carry = s >> 8
+	}
+	if (a.size & 7) != 0 {
+		carry = uint16(bits[len(bits) - 1] >> (a.size & 7))
+	}
+	sum = &BitBlock{
+		bits: bits,
+		size: a.size,
+	}
+	sum.clearPaddingBits()
+	return sum, carry != 0
+}
+
+// SubWithBorrow returns the difference a - b - borrowIn, where a
+// and b are seen as unsigned integers stored in little endian
+// format (the bit at position 0 is the least significant bit).
+// The difference has the same size as a and b, wrapping around
+// if it is negative, and borrowOut reports whether a borrow was
+// needed beyond that size, that is, whether a < b + borrowIn.
+//
+// Since borrowOut can be passed as borrowIn to the next
+// subtraction, integers larger than a BitBlock can be subtracted
+// by chaining the borrows of several BitBlocks. SubWithBorrow
+// panics if a and b do not have the same size.
+func SubWithBorrow(a *BitBlock, b *BitBlock, borrowIn bool) (diff *BitBlock, borrowOut bool) {
+	if a.size != b.size {
+		panic(panicMessageDifferentBitBlockSizes(a.size, b.size))
+	}
+	if a.size == 0 {
+		return NewZeroBitBlock(0), borrowIn
+	}
+	var borrow int16 = 0
+	if borrowIn {
+		borrow = 1
+	}
+	bits := make([]byte, len(a.bits))
+	for i := range bits {
+		d := int16(a.bits[i]) - int16(b.bits[i]) - borrow
+		borrow = 0
+		if d < 0 {
+			d += 256
+			borrow = 1
+		}
+		bits[i] = byte(d)
+	}
+	// The padding bits of a and b are 0, so a borrow at the position
+	// block.Size() goes through the padding bits of the last byte,
+	// setting them to 1, and ends as the borrow of the last byte.
+	diff = &BitBlock{
+		bits: bits,
+		size: a.size,
+	}
+	diff.clearPaddingBits()
+	return diff, borrow != 0
+}
+
+// MulByUint returns a new BitBlock with the same size as this
+// BitBlock, holding the product of m and the unsigned integer
+// stored in little endian format in this BitBlock. The product
+// is truncated to the size of the BitBlock (wrapping around on
+// overflow).
+func (block *BitBlock) MulByUint(m uint64) *BitBlock {
+	bitBlock := NewZeroBitBlock(block.size)
+	var carry uint64 = 0
+	for i, b := range block.bits {
+		hi, lo := bits.Mul64(uint64(b), m)
+		lo, c := bits.Add64(lo, carry, 0)
+		hi += c
+		bitBlock.bits[i] = byte(lo)
+		carry = (lo >> 8) | (hi << 56)
+	}
+	bitBlock.clearPaddingBits()
+	return bitBlock
+}
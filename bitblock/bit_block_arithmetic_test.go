@@ -0,0 +1,185 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"math/big"
+	"testing"
+)
+
+
+// Test the CarrylessMultiply() function.
+func TestCarrylessMultiply(t *testing.T) {
+	type Test struct { id string; a string; b string; product string }
+
+	// Test cases, the products were computed by hand.
+	tests := []Test{
+		Test{ id: "0000", a: "11", b: "11", product: "101" },
+		Test{ id: "0001", a: "101", b: "11", product: "1111" },
+		Test{ id: "0002", a: "1101", b: "011", product: "010111" },
+		Test{ id: "0003", a: "1", b: "10110", product: "10110" },
+		Test{ id: "0004", a: "0", b: "111", product: "000" },
+		Test{ id: "0005", a: "111", b: "111", product: "10101" },
+		Test{ id: "0006", a: "", b: "1011", product: "" },
+		Test{ id: "0007", a: "", b: "", product: "" },
+	}
+
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			// Test the product in both orders, since the carry-less
+			// multiplication is commutative.
+			if ok := checkBitBlockBinaryString(t, CarrylessMultiply(a, b), test.product); !ok {
+				t.Fatalf("wrong answer for CarrylessMultiply(%q, %q), want %q", test.a, test.b, test.product)
+			}
+			if ok := checkBitBlockBinaryString(t, CarrylessMultiply(b, a), test.product); !ok {
+				t.Fatalf("wrong answer for CarrylessMultiply(%q, %q), want %q", test.b, test.a, test.product)
+			}
+		})
+	}
+}
+
+// Test the AddWithCarry() function.
+func TestAddWithCarry(t *testing.T) {
+	// Test all the sums of integers with up to 10 bits, comparing them
+	// with the sums of uint64 numbers.
+	for size := 0; size <= 10; size++ {
+		for x := uint64(0); x < 1 << size; x++ {
+			for y := uint64(0); y < 1 << size; y += 3 {
+				for _, carryIn := range []bool{false, true} {
+					a, b := uint64ToTestBitBlock(x, size), uint64ToTestBitBlock(y, size)
+					correct := x + y
+					if carryIn {
+						correct++
+					}
+					sum, carryOut := AddWithCarry(a, b, carryIn)
+					if ok := checkBitBlockBinaryString(t, sum, uint64ToTestBitBlock(correct, size).ToBinaryString()); !ok {
+						t.Fatalf("wrong sum for AddWithCarry(%d, %d, %t) with size %d", x, y, carryIn, size)
+					}
+					if want := (correct >> size) != 0; carryOut != want {
+						t.Fatalf("got carryOut = %t for AddWithCarry(%d, %d, %t) with size %d, want carryOut = %t", carryOut, x, y, carryIn, size, want)
+					}
+				}
+			}
+		}
+	}
+
+	// Test that chaining the carry of two BitBlocks of 13 bits gives the
+	// same result as adding integers of 26 bits.
+	for _, pair := range [][2]uint64{ {0x3FFFFFF, 1}, {0x1FFF, 1}, {0x2ABCDEF, 0x1543210}, {0x0123456, 0x3FEDCBA}, {0, 0} } {
+		x, y := pair[0], pair[1]
+		low, carry := AddWithCarry(uint64ToTestBitBlock(x & 0x1FFF, 13), uint64ToTestBitBlock(y & 0x1FFF, 13), false)
+		high, carryOut := AddWithCarry(uint64ToTestBitBlock(x >> 13, 13), uint64ToTestBitBlock(y >> 13, 13), carry)
+		correct := x + y
+		if ok := checkBitBlockBinaryString(t, Concatenate(low, high), uint64ToTestBitBlock(correct, 26).ToBinaryString()); !ok {
+			t.Fatalf("wrong chained sum for %d + %d with 26 bits", x, y)
+		}
+		if want := (correct >> 26) != 0; carryOut != want {
+			t.Fatalf("got carryOut = %t for the chained sum %d + %d with 26 bits, want carryOut = %t", carryOut, x, y, want)
+		}
+	}
+
+	// Test that AddWithCarry() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to AddWithCarry(a, b, false) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		AddWithCarry(NewZeroBitBlock(8), NewZeroBitBlock(9), false)
+	}()
+}
+
+// Test the SubWithBorrow() function.
+func TestSubWithBorrow(t *testing.T) {
+	// Test all the differences of integers with up to 8 bits, comparing
+	// them with the differences of uint64 numbers.
+	for size := 0; size <= 8; size++ {
+		for x := uint64(0); x < 1 << size; x++ {
+			for y := uint64(0); y < 1 << size; y += 3 {
+				for _, borrowIn := range []bool{false, true} {
+					a, b := uint64ToTestBitBlock(x, size), uint64ToTestBitBlock(y, size)
+					correct := x - y
+					if borrowIn {
+						correct--
+					}
+					diff, borrowOut := SubWithBorrow(a, b, borrowIn)
+					if ok := checkBitBlockBinaryString(t, diff, uint64ToTestBitBlock(correct, size).ToBinaryString()); !ok {
+						t.Fatalf("wrong difference for SubWithBorrow(%d, %d, %t) with size %d", x, y, borrowIn, size)
+					}
+					want := x < y || (x == y && borrowIn)
+					if borrowOut != want {
+						t.Fatalf("got borrowOut = %t for SubWithBorrow(%d, %d, %t) with size %d, want borrowOut = %t", borrowOut, x, y, borrowIn, size, want)
+					}
+				}
+			}
+		}
+	}
+
+	// Test some differences with exact-zero results and with b > a.
+	type Test struct { id string; a string; b string; borrowIn bool; diff string; borrowOut bool }
+	tests := []Test{
+		Test{ id: "0000", a: "1011011001", b: "1011011001", borrowIn: false, diff: "0000000000", borrowOut: false },
+		Test{ id: "0001", a: "1011011001", b: "0011011001", borrowIn: true, diff: "0000000000", borrowOut: false },
+		Test{ id: "0002", a: "0000000000", b: "1000000000", borrowIn: false, diff: "1111111111", borrowOut: true },
+		Test{ id: "0003", a: "0100000000", b: "1000000000", borrowIn: false, diff: "1000000000", borrowOut: false },
+		Test{ id: "0004", a: "1000000000", b: "0100000000", borrowIn: false, diff: "1111111111", borrowOut: true },
+	}
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			diff, borrowOut := SubWithBorrow(a, b, test.borrowIn)
+			if ok := checkBitBlockBinaryString(t, diff, test.diff); !ok {
+				t.Fatalf("wrong difference for SubWithBorrow(%q, %q, %t), want %q", test.a, test.b, test.borrowIn, test.diff)
+			}
+			if borrowOut != test.borrowOut {
+				t.Fatalf("got borrowOut = %t for SubWithBorrow(%q, %q, %t), want %t", borrowOut, test.a, test.b, test.borrowIn, test.borrowOut)
+			}
+		})
+	}
+
+	// Test that SubWithBorrow() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to SubWithBorrow(a, b, false) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		SubWithBorrow(NewZeroBitBlock(8), NewZeroBitBlock(9), false)
+	}()
+}
+
+// Test the MulByUint() method.
+func TestMulByUint(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	multipliers := []uint64{0, 1, 2, 3, 10, 255, 256, 1000003, 0xFFFFFFFF, 0xFFFFFFFFFFFFFFFF, 0x8000000000000001}
+	for _, size := range []int{0, 1, 7, 8, 9, 15, 16, 31, 64, 65, 100, 104} {
+		bitBlock := BytesToBitBlock(bytes, size)
+
+		// The integer stored in the BitBlock.
+		x := new(big.Int)
+		for i := size - 1; i >= 0; i-- {
+			x.Lsh(x, 1)
+			if bitBlock.Get(i) {
+				x.SetBit(x, 0, 1)
+			}
+		}
+		modulus := new(big.Int).Lsh(big.NewInt(1), uint(size))
+
+		for _, m := range multipliers {
+			// The product computed with big.Int, truncated to the size.
+			product := new(big.Int).Mul(x, new(big.Int).SetUint64(m))
+			product.Mod(product, modulus)
+			correct := make([]byte, size)
+			for i := 0; i < size; i++ {
+				correct[i] = '0' + byte(product.Bit(i))
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock.MulByUint(m), string(correct)); !ok {
+				t.Fatalf("wrong answer for MulByUint(%d) on a BitBlock of size %d", m, size)
+			}
+		}
+	}
+}
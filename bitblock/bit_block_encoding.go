@@ -0,0 +1,352 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"hash/fnv"
+	"math/bits"
+	"strconv"
+)
+
+
+// MarshalRLE returns a run-length encoding of this BitBlock.
+//
+// The encoding starts with the size of the BitBlock, followed by
+// the lengths of the runs of consecutive equal bits, alternating
+// between runs of 0s and runs of 1s and starting with a run of
+// 0s (which is empty if the first bit is 1). All the numbers are
+// encoded as unsigned varints (see encoding/binary).
+//
+// This encoding is much smaller than ToBytes for BitBlocks with
+// long runs of equal bits.
+func (block *BitBlock) MarshalRLE() []byte {
+	buf := make([]byte, binary.MaxVarintLen64)
+	data := []byte{}
+	n := binary.PutUvarint(buf, uint64(block.size))
+	data = append(data, buf[:n]...)
+	current, length := false, 0
+	for pos := 0; pos < block.size; pos++ {
+		if block.Get(pos) != current {
+			n = binary.PutUvarint(buf, uint64(length))
+			data = append(data, buf[:n]...)
+			current, length = !current, 0
+		}
+		length++
+	}
+	if length > 0 {
+		n = binary.PutUvarint(buf, uint64(length))
+		data = append(data, buf[:n]...)
+	}
+	return data
+}
+
+// UnmarshalRLE returns a new BitBlock decoded from data, which
+// must have the format produced by MarshalRLE. UnmarshalRLE
+// panics if data is not a valid run-length encoding, including
+// when the lengths of the runs do not add up to the size.
+func UnmarshalRLE(data []byte) *BitBlock {
+	size, n := binary.Uvarint(data)
+	if n <= 0 {
+		panic(panicMessageInvalidEncodedBitBlock("RLE", "cannot read the size"))
+	}
+	if size > uint64(int(^uint(0) >> 1)) {
+		panic(panicMessageInvalidEncodedBitBlock("RLE", "the size is too large"))
+	}
+	data = data[n:]
+
+	// The lengths of the runs are decoded and checked before
+	// allocating the BitBlock, so that a size that does not match the
+	// runs is rejected without allocating it.
+	lengths := []uint64{}
+	pos := uint64(0)
+	for len(data) > 0 {
+		length, n := binary.Uvarint(data)
+		if n <= 0 {
+			panic(panicMessageInvalidEncodedBitBlock("RLE", "cannot read the length of a run"))
+		}
+		if length > size - pos {
+			panic(panicMessageInvalidEncodedBitBlock("RLE", "the runs exceed the size"))
+		}
+		lengths = append(lengths, length)
+		data = data[n:]
+		pos += length
+	}
+	if pos != size {
+		panic(panicMessageInvalidEncodedBitBlock("RLE", "the runs do not cover the size"))
+	}
+
+	bitBlock := NewZeroBitBlock(int(size))
+	pos = 0
+	for i, length := range lengths {
+		if (i & 1) == 1 {
+			bitBlock.SetRange(int(pos), int(pos + length), true)
+		}
+		pos += length
+	}
+	return bitBlock
+}
+
+// uvarintLen returns the number of bytes used to encode x as an
+// unsigned varint.
+func uvarintLen(x uint64) int {
+	n := 1
+	for x >= 0x80 {
+		x >>= 7
+		n++
+	}
+	return n
+}
+
+// EstimatedCompressedSize returns the number of bytes of the
+// run-length encoding of this BitBlock, that is, the value of
+// len(block.MarshalRLE()), but without building the encoding.
+//
+// It can be compared to len(block.ToBytes()) to cheaply decide
+// whether it is worth storing the BitBlock compressed.
+func (block *BitBlock) EstimatedCompressedSize() int {
+	n := uvarintLen(uint64(block.size))
+	current, length := false, 0
+	for pos := 0; pos < block.size; pos++ {
+		if block.Get(pos) != current {
+			n += uvarintLen(uint64(length))
+			current, length = !current, 0
+		}
+		length++
+	}
+	if length > 0 {
+		n += uvarintLen(uint64(length))
+	}
+	return n
+}
+
+// CRC32Reflected returns the reflected 32-bit CRC of the bytes
+// returned by ToBytes, in which the bits of each byte are
+// processed from the least significant to the most significant
+// one and the result is reflected, as done by CRC-32 (used by
+// Ethernet, zlib and PNG) and CRC-32C.
+//
+// poly is the generator polynomial and init is the initial value
+// of the register, both in their usual (non-reflected) form, as
+// given in the catalogues of CRC algorithms; they are reflected
+// internally. xorOut is XORed with the final (reflected) register.
+// For example, CRC-32 uses poly 0x04C11DB7, init 0xFFFFFFFF and
+// xorOut 0xFFFFFFFF.
+func (block *BitBlock) CRC32Reflected(poly uint32, init uint32, xorOut uint32) uint32 {
+	reflectedPoly := bits.Reverse32(poly)
+	crc := bits.Reverse32(init)
+	for _, b := range block.bits {
+		crc ^= uint32(b)
+		for i := 0; i < 8; i++ {
+			if (crc & 1) != 0 {
+				crc = (crc >> 1) ^ reflectedPoly
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	return crc ^ xorOut
+}
+
+// ComputePatch returns a patch that transforms from into to when
+// passed to ApplyPatch. The patch holds the positions of the bits
+// that are different in from and to, in increasing order, each of
+// them encoded as an unsigned varint (see encoding/binary) with
+// its distance to the previous position (the first one with its
+// distance to position 0). It is much smaller than to.ToBytes()
+// when only a few bits differ. ComputePatch panics if from and to
+// do not have the same size.
+func ComputePatch(from *BitBlock, to *BitBlock) []byte {
+	if from.size != to.size {
+		panic(panicMessageDifferentBitBlockSizes(from.size, to.size))
+	}
+	buf := make([]byte, binary.MaxVarintLen64)
+	patch := []byte{}
+	prev := 0
+	for i := range from.bits {
+		diff := from.bits[i] ^ to.bits[i]
+		for diff != 0 {
+			pos := 8 * i + bits.TrailingZeros8(diff)
+			n := binary.PutUvarint(buf, uint64(pos - prev))
+			patch = append(patch, buf[:n]...)
+			prev = pos
+			diff &= diff - 1
+		}
+	}
+	return patch
+}
+
+// ApplyPatch returns a new BitBlock with the bits of from, but
+// flipping the bits at the positions held by patch, which must
+// have the format produced by ComputePatch. ApplyPatch panics if
+// patch is not valid or holds positions beyond from.Size()-1.
+func ApplyPatch(from *BitBlock, patch []byte) *BitBlock {
+	bitBlock := from.Clone()
+	pos := uint64(0)
+	for first := true; len(patch) > 0; first = false {
+		gap, n := binary.Uvarint(patch)
+		if n <= 0 {
+			panic(panicMessageInvalidEncodedBitBlock("patch", "cannot read a position"))
+		}
+		if gap == 0 && !first {
+			panic(panicMessageInvalidEncodedBitBlock("patch", "the positions are not increasing"))
+		}
+		if gap >= uint64(from.size) - pos {
+			panic(panicMessageInvalidEncodedBitBlock("patch", "the positions exceed the size"))
+		}
+		pos += gap
+		bitBlock.Set(int(pos), !bitBlock.Get(int(pos)))
+		patch = patch[n:]
+	}
+	return bitBlock
+}
+
+// decodeBytes returns a new BitBlock of the given size containing
+// the bits of data, which must hold exactly (size + 7) / 8 bytes
+// with the padding bits set to 0. Otherwise it returns an error
+// whose message includes the name of the encoding. size must not
+// be negative.
+func decodeBytes(encoding string, data []byte, size int) (*BitBlock, error) {
+	if len(data) != (size + 7) / 8 {
+		return nil, errors.New(panicMessageInvalidEncodedBitBlock(encoding, "the number of bytes does not match the size"))
+	}
+	bitBlock := BytesToBitBlock(data, size)
+	if len(data) > 0 && bitBlock.bits[len(data) - 1] != data[len(data) - 1] {
+		return nil, errors.New(panicMessageInvalidEncodedBitBlock(encoding, "some padding bits are set to 1"))
+	}
+	return bitBlock, nil
+}
+
+// MarshalBinary implements the encoding.BinaryMarshaler interface.
+// The encoding is the size of the BitBlock as an unsigned varint
+// (see encoding/binary), followed by the bytes returned by ToBytes.
+// The returned error is always nil.
+func (block *BitBlock) MarshalBinary() ([]byte, error) {
+	data := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64 + len(block.bits))
+	n := binary.PutUvarint(data, uint64(block.size))
+	return append(data[:n], block.bits...), nil
+}
+
+// UnmarshalBinary implements the encoding.BinaryUnmarshaler
+// interface, replacing this BitBlock with the one decoded from
+// data, which must have the format produced by MarshalBinary.
+// UnmarshalBinary returns an error, and leaves this BitBlock
+// unchanged, if data is truncated, if the number of bytes after
+// the size is not (size + 7) / 8 or if some padding bit is set
+// to 1.
+func (block *BitBlock) UnmarshalBinary(data []byte) error {
+	size, n := binary.Uvarint(data)
+	if n <= 0 {
+		return errors.New(panicMessageInvalidEncodedBitBlock("binary", "cannot read the size"))
+	}
+	if size > uint64(int(^uint(0) >> 1)) {
+		return errors.New(panicMessageInvalidEncodedBitBlock("binary", "the size is too large"))
+	}
+	bitBlock, err := decodeBytes("binary", data[n:], int(size))
+	if err != nil {
+		return err
+	}
+	*block = *bitBlock
+	return nil
+}
+
+// jsonBitBlock is the representation of a BitBlock used by
+// MarshalJSON and UnmarshalJSON. The bytes are encoded by the
+// encoding/json package as a base64 string.
+type jsonBitBlock struct {
+	Size int `json:"size"`
+	Bits []byte `json:"bits"`
+}
+
+// MarshalJSON implements the json.Marshaler interface. The
+// BitBlock is encoded as a JSON object with its size and the
+// bytes returned by ToBytes as a standard base64 string, for
+// example {"size":12,"bits":"DwY="}.
+func (block *BitBlock) MarshalJSON() ([]byte, error) {
+	return json.Marshal(jsonBitBlock{ Size: block.size, Bits: block.bits })
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface,
+// replacing this BitBlock with the one decoded from data, which
+// must have the format produced by MarshalJSON. UnmarshalJSON
+// returns an error, and leaves this BitBlock unchanged, if data
+// is not a valid JSON object with that format, if the size is
+// negative, if the number of bytes is not (size + 7) / 8 or if
+// some padding bit is set to 1.
+func (block *BitBlock) UnmarshalJSON(data []byte) error {
+	var decoded jsonBitBlock
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	if decoded.Size < 0 {
+		return errors.New(panicMessageInvalidEncodedBitBlock("JSON", "the size is negative"))
+	}
+	bitBlock, err := decodeBytes("JSON", decoded.Bits, decoded.Size)
+	if err != nil {
+		return err
+	}
+	*block = *bitBlock
+	return nil
+}
+
+// MarshalText implements the encoding.TextMarshaler interface. The
+// BitBlock is encoded as its size in decimal, followed by a colon
+// and the bytes returned by ToBytes as a standard base64 string,
+// for example "12:DwY=". The returned error is always nil.
+func (block *BitBlock) MarshalText() ([]byte, error) {
+	text := strconv.AppendInt(nil, int64(block.size), 10)
+	text = append(text, ':')
+	n := len(text)
+	text = append(text, make([]byte, base64.StdEncoding.EncodedLen(len(block.bits)))...)
+	base64.StdEncoding.Encode(text[n:], block.bits)
+	return text, nil
+}
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface,
+// replacing this BitBlock with the one decoded from text, which
+// must have the format produced by MarshalText. UnmarshalText
+// returns an error, and leaves this BitBlock unchanged, if the
+// size is not a non-negative decimal number followed by a colon,
+// if the rest is not valid base64, if the number of decoded bytes
+// is not (size + 7) / 8 or if some padding bit is set to 1.
+func (block *BitBlock) UnmarshalText(text []byte) error {
+	i := bytes.IndexByte(text, ':')
+	if i < 0 {
+		return errors.New(panicMessageInvalidEncodedBitBlock("text", "the size is not followed by a colon"))
+	}
+	size, err := strconv.ParseUint(string(text[:i]), 10, strconv.IntSize - 1)
+	if err != nil {
+		return errors.New(panicMessageInvalidEncodedBitBlock("text", "cannot read the size"))
+	}
+	data := make([]byte, base64.StdEncoding.DecodedLen(len(text) - i - 1))
+	n, err := base64.StdEncoding.Decode(data, text[i + 1:])
+	if err != nil {
+		return errors.New(panicMessageInvalidEncodedBitBlock("text", "cannot decode the base64 bytes"))
+	}
+	bitBlock, err := decodeBytes("text", data[:n], int(size))
+	if err != nil {
+		return err
+	}
+	*block = *bitBlock
+	return nil
+}
+
+// Sum64 returns the 64-bit FNV-1a hash (see hash/fnv) of the size
+// of this BitBlock, as 8 bytes in little endian format, followed
+// by the bytes returned by ToBytes. Since the padding bits are
+// always 0, equal BitBlocks (see Equal) have the same hash, so it
+// can be used to key maps by bit patterns. This hash is fast but
+// not cryptographic, and collisions can be found easily.
+func (block *BitBlock) Sum64() uint64 {
+	var header [8]byte
+	binary.LittleEndian.PutUint64(header[:], uint64(block.size))
+	h := fnv.New64a()
+	h.Write(header[:])
+	h.Write(block.bits)
+	return h.Sum64()
+}
@@ -0,0 +1,438 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"math/bits"
+	"math/rand"
+	"testing"
+)
+
+
+// Test the MarshalRLE() method and the UnmarshalRLE() function.
+func TestRLE(t *testing.T) {
+	// BitBlock with very long runs of equal bits.
+	longRuns := NewZeroBitBlock(10000)
+	for pos := 1000; pos < 6000; pos++ {
+		longRuns.Set1(pos)
+	}
+	for pos := 9990; pos < 10000; pos++ {
+		longRuns.Set1(pos)
+	}
+
+	bitBlocks := []*BitBlock{
+		longRuns,
+		NewZeroBitBlock(0),
+		NewZeroBitBlock(77),
+		binaryStringToBitBlock("1"),
+		binaryStringToBitBlock("0"),
+		binaryStringToBitBlock("1101000111101010110000011111"),
+		BytesToBitBlock([]byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}, 100),
+	}
+
+	// Test that the BitBlocks are recovered after encoding them.
+	for i, bitBlock := range bitBlocks {
+		bitBlock2 := UnmarshalRLE(bitBlock.MarshalRLE())
+		if ok := checkBitBlockBinaryString(t, bitBlock2, bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("the BitBlock bitBlocks[%d] is different after encoding and decoding it", i)
+		}
+	}
+
+	// Test that the encoding is smaller than ToBytes() for long runs.
+	if n1, n2 := len(longRuns.MarshalRLE()), len(longRuns.ToBytes()); n1 >= n2 {
+		t.Fatalf("got len(MarshalRLE()) = %d and len(ToBytes()) = %d for a BitBlock with long runs, want len(MarshalRLE()) < len(ToBytes())", n1, n2)
+	}
+
+	// Test that UnmarshalRLE() panics on invalid data.
+	for _, data := range [][]byte{ {}, {0x80}, {5, 2}, {5, 2, 4}, {5, 2, 0x80}, {0, 1} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to UnmarshalRLE(%v) did not panic", data)
+				}
+			}()
+			UnmarshalRLE(data)
+		}()
+	}
+
+	// Test that huge sizes whose runs do not cover them panic with the
+	// message of an invalid encoding, before allocating the BitBlock.
+	for _, size := range []uint64{1 << 40, 1 << 62, 1 << 63 - 1} {
+		header := make([]byte, binary.MaxVarintLen64)
+		header = header[:binary.PutUvarint(header, size)]
+		for _, data := range [][]byte{ header, append(header, 5, 2) } {
+			func() {
+				defer func() {
+					panicMessage := recover()
+					if panicMessage == nil {
+						t.Fatalf("the call to UnmarshalRLE(%v) did not panic", data)
+					}
+					if message, ok := panicMessage.(string); !ok || message != panicMessageInvalidEncodedBitBlock("RLE", "the runs do not cover the size") {
+						t.Fatalf("got panic %v from UnmarshalRLE(%v), want a panic for runs that do not cover the size", panicMessage, data)
+					}
+				}()
+				UnmarshalRLE(data)
+			}()
+		}
+	}
+}
+
+// Test the EstimatedCompressedSize() method.
+func TestEstimatedCompressedSize(t *testing.T) {
+	// BitBlocks with runs of several lengths, so that the varints of
+	// the run lengths have different sizes.
+	bitBlocks := []*BitBlock{
+		NewZeroBitBlock(0),
+		NewZeroBitBlock(1),
+		NewZeroBitBlock(127),
+		NewZeroBitBlock(128),
+		NewZeroBitBlock(20000),
+		binaryStringToBitBlock("1"),
+		binaryStringToBitBlock("1101000111101010110000011111"),
+		BytesToBitBlock([]byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}, 100),
+	}
+	for _, runs := range [][]int{ {0, 127, 128, 1}, {300, 20000, 5, 16384}, {1, 1, 1, 200, 3} } {
+		bitBlock := NewZeroBitBlock(0)
+		for i, length := range runs {
+			run := NewZeroBitBlock(length)
+			if i % 2 == 1 {
+				for pos := 0; pos < length; pos++ {
+					run.Set1(pos)
+				}
+			}
+			bitBlock = Concatenate(bitBlock, run)
+		}
+		bitBlocks = append(bitBlocks, bitBlock)
+	}
+
+	for i, bitBlock := range bitBlocks {
+		if n1, n2 := bitBlock.EstimatedCompressedSize(), len(bitBlock.MarshalRLE()); n1 != n2 {
+			t.Fatalf("got EstimatedCompressedSize() = %d for bitBlocks[%d], want len(MarshalRLE()) = %d", n1, i, n2)
+		}
+	}
+}
+
+// Test the CRC32Reflected() method.
+func TestCRC32Reflected(t *testing.T) {
+	type Test struct { id string; poly uint32; init uint32; xorOut uint32; check uint32 }
+
+	// Test cases, the check values are the CRCs of the ASCII string
+	// "123456789" given in the catalogue of parametrised CRC algorithms.
+	tests := []Test{
+		Test{ id: "CRC-32", poly: 0x04C11DB7, init: 0xFFFFFFFF, xorOut: 0xFFFFFFFF, check: 0xCBF43926 },
+		Test{ id: "CRC-32C", poly: 0x1EDC6F41, init: 0xFFFFFFFF, xorOut: 0xFFFFFFFF, check: 0xE3069283 },
+		Test{ id: "CRC-32/JAMCRC", poly: 0x04C11DB7, init: 0xFFFFFFFF, xorOut: 0x00000000, check: 0x340BC6D9 },
+		Test{ id: "CRC-32D", poly: 0xA833982B, init: 0xFFFFFFFF, xorOut: 0xFFFFFFFF, check: 0x87315576 },
+	}
+
+	data := []byte("123456789")
+	bitBlock := BytesToBitBlock(data, 8 * len(data))
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			if crc := bitBlock.CRC32Reflected(test.poly, test.init, test.xorOut); crc != test.check {
+				t.Fatalf("got CRC32Reflected(%#08x, %#08x, %#08x) = %#08x, want %#08x", test.poly, test.init, test.xorOut, crc, test.check)
+			}
+		})
+	}
+
+	// Test against the hash/crc32 package for several BitBlocks.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		if crc, want := bitBlock.CRC32Reflected(0x04C11DB7, 0xFFFFFFFF, 0xFFFFFFFF), crc32.ChecksumIEEE(bitBlock.ToBytes()); crc != want {
+			t.Fatalf("got CRC32Reflected() = %#08x on a BitBlock of size %d, want %#08x", crc, size, want)
+		}
+	}
+
+	// Test initial values that change when they are bit-reversed,
+	// against a straightforward implementation of the CRC that shifts
+	// the non-reflected register toward the most significant bit,
+	// reversing each input byte and the final register.
+	reference := func(data []byte, poly uint32, init uint32, xorOut uint32) uint32 {
+		crc := init
+		for _, b := range data {
+			crc ^= uint32(bits.Reverse8(b)) << 24
+			for i := 0; i < 8; i++ {
+				if (crc & 0x80000000) != 0 {
+					crc = (crc << 1) ^ poly
+				} else {
+					crc <<= 1
+				}
+			}
+		}
+		return bits.Reverse32(crc) ^ xorOut
+	}
+	for _, init := range []uint32{0x00000001, 0x12345678, 0x80000000, 0xFFFF0000, 0xDEADBEEF} {
+		for _, poly := range []uint32{0x04C11DB7, 0x1EDC6F41} {
+			for _, size := range []int{0, 1, 8, 50, 8 * len(bytes)} {
+				bitBlock := BytesToBitBlock(bytes, size)
+				if crc, want := bitBlock.CRC32Reflected(poly, init, 0xFFFFFFFF), reference(bitBlock.ToBytes(), poly, init, 0xFFFFFFFF); crc != want {
+					t.Fatalf("got CRC32Reflected(%#08x, %#08x, 0xffffffff) = %#08x on a BitBlock of size %d, want %#08x", poly, init, crc, size, want)
+				}
+			}
+		}
+	}
+}
+
+// Test the ComputePatch() and ApplyPatch() functions.
+func TestPatch(t *testing.T) {
+	r := rand.New(rand.NewSource(1776))
+
+	// Test that applying the patch recovers the target BitBlock.
+	for i := 0; i < 200; i++ {
+		size := r.Intn(300)
+		from, to := RandomBitBlock(size, r), RandomBitBlock(size, r)
+		patch := ComputePatch(from, to)
+		if result := ApplyPatch(from, patch); !result.Equal(to) {
+			t.Fatalf("got a different BitBlock after applying the patch on BitBlocks of size %d", size)
+		}
+		if result := ApplyPatch(from, ComputePatch(from, from)); !result.Equal(from) {
+			t.Fatalf("got a different BitBlock after applying an empty patch on a BitBlock of size %d", size)
+		}
+	}
+
+	// Test that the patch of a small difference is much smaller than the
+	// bytes of the target BitBlock.
+	from := RandomBitBlock(10000, r)
+	to := from.Clone()
+	for _, pos := range []int{0, 17, 5000, 5001, 9999} {
+		to.Set(pos, !to.Get(pos))
+	}
+	patch := ComputePatch(from, to)
+	if len(patch) * 20 > len(to.ToBytes()) {
+		t.Fatalf("got a patch of %d bytes for 5 different bits, want a patch much smaller than the %d bytes of the BitBlock", len(patch), len(to.ToBytes()))
+	}
+	if result := ApplyPatch(from, patch); !result.Equal(to) {
+		t.Fatalf("got a different BitBlock after applying a small patch")
+	}
+
+	// Test that ComputePatch() panics if the sizes are different and
+	// that ApplyPatch() panics on invalid patches.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to ComputePatch(from, to) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		ComputePatch(NewZeroBitBlock(8), NewZeroBitBlock(9))
+	}()
+	for _, patch := range [][]byte{ {0x80}, {10}, {3, 0}, {3, 7} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to ApplyPatch(from, %v) on a BitBlock of size 10 did not panic", patch)
+				}
+			}()
+			ApplyPatch(NewZeroBitBlock(10), patch)
+		}()
+	}
+}
+
+// Test the MarshalBinary() and UnmarshalBinary() methods.
+func TestMarshalBinary(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		data, err := bitBlock.MarshalBinary()
+		if err != nil {
+			t.Fatalf("got error %q from MarshalBinary() on a BitBlock of size %d", err, size)
+		}
+		result := NewZeroBitBlock(3)
+		if err := result.UnmarshalBinary(data); err != nil {
+			t.Fatalf("got error %q from UnmarshalBinary() on the encoding of a BitBlock of size %d", err, size)
+		}
+		if ok := checkBitBlockBinaryString(t, result, bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock after the round trip of a BitBlock of size %d", size)
+		}
+	}
+
+	// Test that UnmarshalBinary() returns an error on invalid data and
+	// leaves the BitBlock unchanged.
+	invalid := [][]byte{
+		{},
+		{0x80},
+		{10, 255},
+		{10, 255, 3, 0},
+		{8},
+		{7, 128},
+		{12, 255, 16},
+		{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01},
+	}
+	for _, data := range invalid {
+		result := BytesToBitBlock(bytes, 20)
+		if err := result.UnmarshalBinary(data); err == nil {
+			t.Fatalf("got nil error from UnmarshalBinary(%v), want an error", data)
+		}
+		if ok := checkBitBlockBinaryString(t, result, BytesToBitBlock(bytes, 20).ToBinaryString()); !ok {
+			t.Fatalf("the call to UnmarshalBinary(%v) modified the BitBlock", data)
+		}
+	}
+}
+
+// Test the MarshalJSON() and UnmarshalJSON() methods.
+func TestMarshalJSON(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test the documented format.
+	data, err := json.Marshal(BytesToBitBlock(bytes, 12))
+	if err != nil {
+		t.Fatalf("got error %q from json.Marshal() on a BitBlock", err)
+	}
+	if want := `{"size":12,"bits":"DwY="}`; string(data) != want {
+		t.Fatalf("got %s from json.Marshal() on a BitBlock, want %s", data, want)
+	}
+
+	// Test the round trip inside a struct with encoding/json.
+	type Config struct { Name string; Mask *BitBlock }
+	for size := 0; size <= 8 * len(bytes); size++ {
+		config := Config{ Name: "mask", Mask: BytesToBitBlock(bytes, size) }
+		data, err := json.Marshal(config)
+		if err != nil {
+			t.Fatalf("got error %q from json.Marshal() on a BitBlock of size %d", err, size)
+		}
+		var result Config
+		if err := json.Unmarshal(data, &result); err != nil {
+			t.Fatalf("got error %q from json.Unmarshal(%s)", err, data)
+		}
+		if ok := checkBitBlockBinaryString(t, result.Mask, config.Mask.ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock after the JSON round trip of a BitBlock of size %d", size)
+		}
+	}
+
+	// Test that UnmarshalJSON() returns an error on invalid data and
+	// leaves the BitBlock unchanged.
+	invalid := []string{
+		`[]`,
+		`{"size":"12","bits":"DwY="}`,
+		`{"size":12,"bits":"DwY"}`,
+		`{"size":-1,"bits":""}`,
+		`{"size":17,"bits":"DwY="}`,
+		`{"size":9,"bits":"DwY="}`,
+		`{"size":10,"bits":"DwY="}`,
+	}
+	for _, data := range invalid {
+		result := BytesToBitBlock(bytes, 20)
+		if err := result.UnmarshalJSON([]byte(data)); err == nil {
+			t.Fatalf("got nil error from UnmarshalJSON(%s), want an error", data)
+		}
+		if ok := checkBitBlockBinaryString(t, result, BytesToBitBlock(bytes, 20).ToBinaryString()); !ok {
+			t.Fatalf("the call to UnmarshalJSON(%s) modified the BitBlock", data)
+		}
+	}
+}
+
+// Test the MarshalText() and UnmarshalText() methods.
+func TestMarshalText(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test the documented format.
+	type Test struct { id string; size int; text string }
+	tests := []Test{
+		Test{ id: "0000", size: 0, text: "0:" },
+		Test{ id: "0001", size: 3, text: "3:Bw==" },
+		Test{ id: "0002", size: 12, text: "12:DwY=" },
+		Test{ id: "0003", size: 24, text: "24:DzZ/" },
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			text, err := BytesToBitBlock(bytes, test.size).MarshalText()
+			if err != nil {
+				t.Fatalf("got error %q from MarshalText() on a BitBlock of size %d", err, test.size)
+			}
+			if string(text) != test.text {
+				t.Fatalf("got MarshalText() = %q on a BitBlock of size %d, want %q", text, test.size, test.text)
+			}
+		})
+	}
+
+	// Test the round trip across many sizes.
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		text, _ := bitBlock.MarshalText()
+		result := NewZeroBitBlock(3)
+		if err := result.UnmarshalText(text); err != nil {
+			t.Fatalf("got error %q from UnmarshalText(%q)", err, text)
+		}
+		if ok := checkBitBlockBinaryString(t, result, bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock after the text round trip of a BitBlock of size %d", size)
+		}
+	}
+
+	// Test that UnmarshalText() returns an error on malformed input and
+	// leaves the BitBlock unchanged.
+	invalid := []string{
+		"",
+		"12",
+		"DwY=",
+		":DwY=",
+		"-4:Dw==",
+		"+12:DwY=",
+		"1x:DwY=",
+		"99999999999999999999999:DwY=",
+		"12:DwY",
+		"12:Dw!=",
+		"17:DwY=",
+		"9:DwY=",
+		"10:DwY=",
+		"0:Dw==",
+	}
+	for _, text := range invalid {
+		result := BytesToBitBlock(bytes, 20)
+		if err := result.UnmarshalText([]byte(text)); err == nil {
+			t.Fatalf("got nil error from UnmarshalText(%q), want an error", text)
+		}
+		if ok := checkBitBlockBinaryString(t, result, BytesToBitBlock(bytes, 20).ToBinaryString()); !ok {
+			t.Fatalf("the call to UnmarshalText(%q) modified the BitBlock", text)
+		}
+	}
+}
+
+// Test the Sum64() method.
+func TestSum64(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test that equal BitBlocks have the same hash, even when they were
+	// built differently.
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		other := binaryStringToBitBlock(bitBlock.ToBinaryString())
+		if h1, h2 := bitBlock.Sum64(), other.Sum64(); h1 != h2 {
+			t.Fatalf("got different hashes %#x and %#x for equal BitBlocks of size %d", h1, h2, size)
+		}
+	}
+
+	// Test the hash of the empty BitBlock, which is the FNV-1a hash of
+	// 8 zero bytes.
+	if h, want := NewZeroBitBlock(0).Sum64(), uint64(0xA8C7F832281A39C5); h != want {
+		t.Fatalf("got Sum64() = %#x on an empty BitBlock, want %#x", h, want)
+	}
+
+	// Test that a few distinct BitBlocks have different hashes,
+	// including BitBlocks with the same bytes but different sizes.
+	distinct := []*BitBlock{
+		NewZeroBitBlock(0),
+		NewZeroBitBlock(1),
+		NewZeroBitBlock(8),
+		NewZeroBitBlock(9),
+		binaryStringToBitBlock("1"),
+		binaryStringToBitBlock("10"),
+		binaryStringToBitBlock("01"),
+		BytesToBitBlock(bytes, 100),
+		BytesToBitBlock(bytes, 104),
+	}
+	hashes := map[uint64]int{}
+	for i, bitBlock := range distinct {
+		h := bitBlock.Sum64()
+		if j, ok := hashes[h]; ok {
+			t.Fatalf("got the same hash %#x for the distinct BitBlocks %d and %d", h, j, i)
+		}
+		hashes[h] = i
+	}
+}
@@ -0,0 +1,112 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+
+// ReadBitBlock reads exactly (size + 7) / 8 bytes from r and
+// returns a new BitBlock of the given size containing the first
+// size bits of those bytes, with the padding bits set to 0.
+//
+// If r has fewer bytes than needed, the error returned by
+// io.ReadFull is returned (io.EOF if no bytes were read and
+// io.ErrUnexpectedEOF otherwise). ReadBitBlock also returns an
+// error if size < 0.
+func ReadBitBlock(r io.Reader, size int) (*BitBlock, error) {
+	if size < 0 {
+		return nil, errors.New(panicMessageNegativeSize(size))
+	}
+	bits := make([]byte, (size + 7) / 8)
+	if _, err := io.ReadFull(r, bits); err != nil {
+		return nil, err
+	}
+	bitBlock := &BitBlock{
+		bits: bits,
+		size: size,
+	}
+	bitBlock.clearPaddingBits()
+	return bitBlock, nil
+}
+
+// WriteTo implements the io.WriterTo interface, writing to w the
+// same bytes returned by MarshalBinary, that is, a header with the
+// size of this BitBlock as an unsigned varint followed by the bytes
+// returned by ToBytes. These bytes can be read back with ReadFrom.
+//
+// WriteTo returns the number of bytes written and the error
+// returned by w, if any. If w writes fewer bytes than requested
+// without an error, io.ErrShortWrite is returned.
+func (block *BitBlock) WriteTo(w io.Writer) (int64, error) {
+	data, _ := block.MarshalBinary()
+	n, err := w.Write(data)
+	if err == nil && n < len(data) {
+		err = io.ErrShortWrite
+	}
+	return int64(n), err
+}
+
+// countingByteReader is an io.ByteReader that reads the bytes of
+// r one at a time, counting them.
+type countingByteReader struct {
+	r io.Reader
+	n int64
+}
+
+// ReadByte reads a single byte from br.r and increments br.n.
+// It returns the error returned by io.ReadFull if the byte cannot
+// be read, without incrementing br.n.
+func (br *countingByteReader) ReadByte() (byte, error) {
+	var buf [1]byte
+	if _, err := io.ReadFull(br.r, buf[:]); err != nil {
+		return 0, err
+	}
+	br.n++
+	return buf[0], nil
+}
+
+// ReadFrom implements the io.ReaderFrom interface, replacing this
+// BitBlock with the one read from r, which must have the format
+// written by WriteTo. Only the bytes of that BitBlock are read from
+// r, the header being read one byte at a time.
+//
+// ReadFrom returns the number of bytes read and an error, leaving
+// this BitBlock unchanged, if r ends before the whole BitBlock is
+// read (io.EOF if no bytes were read and io.ErrUnexpectedEOF
+// otherwise), if the header is not valid or if some padding bit is
+// set to 1.
+func (block *BitBlock) ReadFrom(r io.Reader) (int64, error) {
+	br := &countingByteReader{ r: r }
+	size, err := binary.ReadUvarint(br)
+	if err != nil {
+		if err == io.EOF && br.n > 0 {
+			err = io.ErrUnexpectedEOF
+		}
+		return br.n, err
+	}
+	if size > uint64(int(^uint(0) >> 1)) {
+		return br.n, errors.New(panicMessageInvalidEncodedBitBlock("binary", "the size is too large"))
+	}
+	// The size comes from r, so the buffer only grows with the bytes
+	// actually read instead of being allocated in advance.
+	var data bytes.Buffer
+	n, err := io.CopyN(&data, r, int64((size + 7) / 8))
+	if err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return br.n + n, err
+	}
+	bitBlock, err := decodeBytes("binary", data.Bytes(), int(size))
+	if err != nil {
+		return br.n + n, err
+	}
+	*block = *bitBlock
+	return br.n + n, nil
+}
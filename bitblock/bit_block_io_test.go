@@ -0,0 +1,204 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+
+// Test the ReadBitBlock() function.
+func TestReadBitBlock(t *testing.T) {
+	data := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test that reading from a reader with enough bytes gives the same
+	// BitBlock as BytesToBitBlock(), and only the needed bytes are read.
+	for size := 0; size <= 8 * len(data); size++ {
+		r := bytes.NewReader(data)
+		bitBlock, err := ReadBitBlock(r, size)
+		if err != nil {
+			t.Fatalf("got error %q for ReadBitBlock(r, %d) with %d bytes available, want nil", err, size, len(data))
+		}
+		if ok := checkBitBlockBinaryString(t, bitBlock, BytesToBitBlock(data, size).ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock for ReadBitBlock(r, %d)", size)
+		}
+		if n := r.Len(); n != len(data) - (size + 7) / 8 {
+			t.Fatalf("got %d unread bytes after ReadBitBlock(r, %d), want %d", n, size, len(data) - (size + 7) / 8)
+		}
+	}
+
+	// Test that reading from a reader with too few bytes returns an error.
+	type Test struct { id string; available int; size int; err error }
+	tests := []Test{
+		Test{ id: "0000", available: 0, size: 1, err: io.EOF },
+		Test{ id: "0001", available: 2, size: 17, err: io.ErrUnexpectedEOF },
+		Test{ id: "0002", available: 12, size: 8 * len(data), err: io.ErrUnexpectedEOF },
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock, err := ReadBitBlock(bytes.NewReader(data[:test.available]), test.size)
+			if err != test.err {
+				t.Fatalf("got error %v for ReadBitBlock(r, %d) with %d bytes available, want %v", err, test.size, test.available, test.err)
+			}
+			if bitBlock != nil {
+				t.Fatalf("got a non-nil BitBlock for ReadBitBlock(r, %d) with %d bytes available, want nil", test.size, test.available)
+			}
+		})
+	}
+
+	// Test that a negative size returns an error.
+	if _, err := ReadBitBlock(bytes.NewReader(data), -3); err == nil {
+		t.Fatalf("got nil error for ReadBitBlock(r, -3), want an error")
+	}
+}
+
+// shortWriter is an io.Writer that accepts at most limit bytes,
+// returning err once the limit is reached.
+type shortWriter struct {
+	buf bytes.Buffer
+	limit int
+	err error
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) <= w.limit {
+		w.limit -= len(p)
+		return w.buf.Write(p)
+	}
+	n, _ := w.buf.Write(p[:w.limit])
+	w.limit = 0
+	return n, w.err
+}
+
+// Test the WriteTo() method.
+func TestWriteTo(t *testing.T) {
+	data := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test that the written bytes are the size as an unsigned varint
+	// followed by the bytes of the BitBlock.
+	for size := 0; size <= 8 * len(data); size++ {
+		bitBlock := BytesToBitBlock(data, size)
+		var buf bytes.Buffer
+		buf.WriteString("prefix")
+		n, err := bitBlock.WriteTo(&buf)
+		if err != nil {
+			t.Fatalf("got error %q from WriteTo(w) on a BitBlock of size %d, want nil", err, size)
+		}
+		want := append([]byte{ byte(size) }, bitBlock.ToBytes()...)
+		if size >= 128 {
+			want = append([]byte{ byte(size) | 0x80, byte(size >> 7) }, bitBlock.ToBytes()...)
+		}
+		if n != int64(len(want)) {
+			t.Fatalf("got %d bytes written by WriteTo(w) on a BitBlock of size %d, want %d", n, size, len(want))
+		}
+		if got := buf.Bytes()[len("prefix"):]; !bytes.Equal(got, want) {
+			t.Fatalf("got bytes %v written by WriteTo(w) on a BitBlock of size %d, want %v", got, size, want)
+		}
+	}
+
+	// Test that short writes return the partial count and an error.
+	type Test struct { id string; limit int; writerErr error; err error }
+	tests := []Test{
+		Test{ id: "0000", limit: 0, writerErr: io.ErrClosedPipe, err: io.ErrClosedPipe },
+		Test{ id: "0001", limit: 5, writerErr: io.ErrClosedPipe, err: io.ErrClosedPipe },
+		Test{ id: "0002", limit: 5, writerErr: nil, err: io.ErrShortWrite },
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			w := &shortWriter{ limit: test.limit, err: test.writerErr }
+			n, err := BytesToBitBlock(data, 100).WriteTo(w)
+			if err != test.err {
+				t.Fatalf("got error %v from WriteTo(w) with a limit of %d bytes, want %v", err, test.limit, test.err)
+			}
+			if n != int64(test.limit) {
+				t.Fatalf("got %d bytes written by WriteTo(w) with a limit of %d bytes, want %d", n, test.limit, test.limit)
+			}
+		})
+	}
+}
+
+// Test the ReadFrom() method.
+func TestReadFrom(t *testing.T) {
+	data := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test the round trip of several BitBlocks written with WriteTo()
+	// to one end of a pipe and read with ReadFrom() from the other.
+	sizes := []int{0, 1, 7, 8, 9, 50, 64, 100, 104}
+	r, w := io.Pipe()
+	go func() {
+		for _, size := range sizes {
+			BytesToBitBlock(data, size).WriteTo(w)
+		}
+		w.Close()
+	}()
+	for _, size := range sizes {
+		bitBlock := NewZeroBitBlock(5)
+		n, err := bitBlock.ReadFrom(r)
+		if err != nil {
+			t.Fatalf("got error %q from ReadFrom(r) on a BitBlock of size %d, want nil", err, size)
+		}
+		want := BytesToBitBlock(data, size)
+		if !bitBlock.Equal(want) {
+			t.Fatalf("got %q from ReadFrom(r), want %q", bitBlock.ToBinaryString(), want.ToBinaryString())
+		}
+		if wantN := int64(uvarintLen(uint64(size)) + (size + 7) / 8); n != wantN {
+			t.Fatalf("got %d bytes read by ReadFrom(r) on a BitBlock of size %d, want %d", n, size, wantN)
+		}
+	}
+	if _, err := NewZeroBitBlock(0).ReadFrom(r); err != io.EOF {
+		t.Fatalf("got error %v from ReadFrom(r) after the last BitBlock, want %v", err, io.EOF)
+	}
+
+	// Test that invalid or truncated input returns an error and leaves
+	// the BitBlock unchanged. A nil err in a test case means that any
+	// non-nil error is accepted.
+	type Test struct { id string; input []byte; n int64; err error }
+	tests := []Test{
+		Test{ id: "0000", input: []byte{}, n: 0, err: io.EOF },
+		Test{ id: "0001", input: []byte{0x80}, n: 1, err: io.ErrUnexpectedEOF },
+		Test{ id: "0002", input: []byte{16, 255}, n: 2, err: io.ErrUnexpectedEOF },
+		Test{ id: "0003", input: []byte{17}, n: 1, err: io.ErrUnexpectedEOF },
+		Test{ id: "0004", input: []byte{10, 255, 255}, n: 3, err: nil },
+		Test{ id: "0005", input: []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x01}, n: 10, err: nil },
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock := BytesToBitBlock(data, 20)
+			n, err := bitBlock.ReadFrom(bytes.NewReader(test.input))
+			if err == nil || (test.err != nil && err != test.err) {
+				t.Fatalf("got error %v from ReadFrom(r) with input %v, want %v", err, test.input, test.err)
+			}
+			if n != test.n {
+				t.Fatalf("got %d bytes read by ReadFrom(r) with input %v, want %d", n, test.input, test.n)
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock, BytesToBitBlock(data, 20).ToBinaryString()); !ok {
+				t.Fatalf("the call to ReadFrom(r) with input %v modified the BitBlock", test.input)
+			}
+		})
+	}
+
+	// Test that a huge declared size with little or no data returns an
+	// error instead of allocating the whole BitBlock in advance.
+	for _, size := range []uint64{1 << 40, 1 << 62, 1 << 63 - 1} {
+		for _, available := range []int{0, 5} {
+			header := make([]byte, binary.MaxVarintLen64)
+			header = header[:binary.PutUvarint(header, size)]
+			input := append(header, data[:available]...)
+			bitBlock := BytesToBitBlock(data, 20)
+			n, err := bitBlock.ReadFrom(bytes.NewReader(input))
+			if err != io.ErrUnexpectedEOF {
+				t.Fatalf("got error %v from ReadFrom(r) with a declared size of %d and %d bytes available, want %v", err, size, available, io.ErrUnexpectedEOF)
+			}
+			if n != int64(len(input)) {
+				t.Fatalf("got %d bytes read by ReadFrom(r) with a declared size of %d, want %d", n, size, len(input))
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock, BytesToBitBlock(data, 20).ToBinaryString()); !ok {
+				t.Fatalf("the call to ReadFrom(r) with a declared size of %d modified the BitBlock", size)
+			}
+		}
+	}
+}
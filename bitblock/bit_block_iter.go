@@ -0,0 +1,43 @@
+// LICENCE NOT YET DEFINED.
+
+//go:build go1.23
+
+package bitblock
+
+
+import (
+	"iter"
+)
+
+
+// All returns an iterator over the positions of this BitBlock and
+// the values of their bits, in increasing order of position, to
+// be used as follows:
+//
+//     for i, value := range block.All() {
+//         ...
+//     }
+//
+// The iteration stops as soon as the loop is broken.
+func (block *BitBlock) All() iter.Seq2[int, bool] {
+	return func(yield func(int, bool) bool) {
+		for pos := 0; pos < block.size; pos++ {
+			if !yield(pos, block.Get(pos)) {
+				return
+			}
+		}
+	}
+}
+
+// SetBits returns an iterator over the positions of the bits set
+// to 1 in this BitBlock, in increasing order, found with
+// FindNextSet. The iteration stops as soon as the loop is broken.
+func (block *BitBlock) SetBits() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		for pos := block.FindNextSet(0); pos != -1; pos = block.FindNextSet(pos + 1) {
+			if !yield(pos) {
+				return
+			}
+		}
+	}
+}
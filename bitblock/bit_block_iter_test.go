@@ -0,0 +1,77 @@
+// LICENCE NOT YET DEFINED.
+
+//go:build go1.23
+
+package bitblock
+
+
+import (
+	"testing"
+)
+
+
+// Test the All() and SetBits() methods.
+func TestIterators(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+
+		// Test that All() yields every position with its value.
+		count := 0
+		for i, value := range bitBlock.All() {
+			if i != count {
+				t.Fatalf("got position %d from All() after %d positions on a BitBlock of size %d, want %d", i, count, size, count)
+			}
+			if value != bitBlock.Get(i) {
+				t.Fatalf("got value %t for position %d from All() on a BitBlock of size %d, want %t", value, i, size, bitBlock.Get(i))
+			}
+			count++
+		}
+		if count != size {
+			t.Fatalf("got %d positions from All() on a BitBlock of size %d, want %d", count, size, size)
+		}
+
+		// Test that SetBits() yields the positions of the bits set to 1.
+		setBits := []int{}
+		for i := range bitBlock.SetBits() {
+			setBits = append(setBits, i)
+		}
+		correct := []int{}
+		for i := 0; i < size; i++ {
+			if bitBlock.Get(i) {
+				correct = append(correct, i)
+			}
+		}
+		if len(setBits) != len(correct) {
+			t.Fatalf("got %d positions from SetBits() on a BitBlock of size %d, want %d", len(setBits), size, len(correct))
+		}
+		for k := range correct {
+			if setBits[k] != correct[k] {
+				t.Fatalf("got %v from SetBits() on a BitBlock of size %d, want %v", setBits, size, correct)
+			}
+		}
+	}
+
+	// Test that breaking the loops stops the iteration.
+	bitBlock := BytesToBitBlock(bytes, 8 * len(bytes))
+	count := 0
+	for i := range bitBlock.All() {
+		count++
+		if i == 10 {
+			break
+		}
+	}
+	if count != 11 {
+		t.Fatalf("got %d iterations of All() when breaking at position 10, want 11", count)
+	}
+	count = 0
+	for i := range bitBlock.SetBits() {
+		count++
+		if i >= 20 {
+			break
+		}
+	}
+	if want := 13; count != want {
+		t.Fatalf("got %d iterations of SetBits() when breaking at the first position >= 20, want %d", count, want)
+	}
+}
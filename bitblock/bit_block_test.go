@@ -14,6 +14,8 @@ package bitblock
 
 
 import (
+	"math"
+	"math/rand"
 	"testing"
 	"unsafe"
 )
@@ -60,6 +62,43 @@ func checkPaddingBits(t *testing.T, bitBlock *BitBlock) bool {
 	return true
 }
 
+// binaryStringToBitBlock returns a new BitBlock whose bits are
+// those represented by the binary string s, where s[i] is the
+// value of the bit at position i.
+func binaryStringToBitBlock(s string) *BitBlock {
+	bitBlock := NewZeroBitBlock(len(s))
+	for i := 0; i < len(s); i++ {
+		bitBlock.Set(i, s[i] == '1')
+	}
+	return bitBlock
+}
+
+// uint64ToTestBitBlock returns a new BitBlock of the given size
+// holding the size least significant bits of x in little endian
+// format.
+func uint64ToTestBitBlock(x uint64, size int) *BitBlock {
+	bitBlock := NewZeroBitBlock(size)
+	for i := 0; i < size && i < 64; i++ {
+		bitBlock.Set(i, (x >> i) & 1 == 1)
+	}
+	return bitBlock
+}
+
+// checkBitBlockBinaryString checks that bitBlock has the bits
+// represented by the binary string correct and that its padding
+// bits are set to 0; if not, an error describing it will be
+// printed.
+func checkBitBlockBinaryString(t *testing.T, bitBlock *BitBlock, correct string) bool {
+	bools := make([]bool, len(correct))
+	for i := 0; i < len(correct); i++ {
+		bools[i] = correct[i] == '1'
+	}
+	if !checkBitBlockValues(t, bitBlock, bools) {
+		return false
+	}
+	return checkPaddingBits(t, bitBlock)
+}
+
 // Test the functions to create a new BitBlock: NewZeroBitBlock and BytesToBitBlock.
 // Test the BitBlock methods: Get, Set0, Set1, Set, ToBinaryString, RemoveFirstBits,
 // RemoveLastBits, GetSubBlock and ToBytes.
@@ -1019,6 +1058,223 @@ func TestConversionBetweenIntegerNumbersAndBitBlocks(t *testing.T) {
 	}
 }
 
+// Test the conversions between integer numbers and BitBlocks in
+// big endian format.
+func TestBigEndianConversionBetweenIntegerNumbersAndBitBlocks(t *testing.T) {
+	// reversed returns the bytes of bitBlock in reverse order.
+	reversed := func(bitBlock *BitBlock) []byte {
+		bytes := bitBlock.ToBytes()
+		for i, j := 0, len(bytes) - 1; i < j; i, j = i+1, j-1 {
+			bytes[i], bytes[j] = bytes[j], bytes[i]
+		}
+		return bytes
+	}
+	equalBytes := func(a []byte, b []byte) bool {
+		if len(a) != len(b) {
+			return false
+		}
+		for i := range a {
+			if a[i] != b[i] {
+				return false
+			}
+		}
+		return true
+	}
+
+	// Test the functions Uint16ToBitBlockBE and BitBlockToUint16BE,
+	// and their signed variants.
+	uint16Numbers := []uint16{541, 3, 8941, 0, 65535, 65534, 3165, 65415, 19811, 132, 127}
+	for _, x := range uint16Numbers {
+		bitBlock := Uint16ToBitBlockBE(x)
+		if !equalBytes(bitBlock.ToBytes(), reversed(Uint16ToBitBlock(x))) {
+			t.Fatalf("the BitBlock obtained by calling Uint16ToBitBlockBE(%d) = %s does not have the bytes of Uint16ToBitBlock(%d) in reverse order", x, bitBlock.ToBinaryString(), x)
+		}
+		if x2 := BitBlockToUint16BE(bitBlock); x != x2 {
+			t.Fatalf("the uint16 obtained by calling BitBlockToUint16BE(%s) = %d is wrong, want %d", bitBlock.ToBinaryString(), x2, x)
+		}
+		if !Int16ToBitBlockBE(int16(x)).Equal(bitBlock) || BitBlockToInt16BE(bitBlock) != int16(x) {
+			t.Fatalf("wrong conversion between the int16 %d and a BitBlock in big endian format", int16(x))
+		}
+	}
+
+	// Test the functions Uint32ToBitBlockBE and BitBlockToUint32BE,
+	// and their signed variants.
+	uint32Numbers := []uint32{615, 8942132, 12314531, 0, 1, 41, 4294967295, 4294967114, 2015617849, 654641, 1064541654, 2313516547}
+	for _, x := range uint32Numbers {
+		bitBlock := Uint32ToBitBlockBE(x)
+		if !equalBytes(bitBlock.ToBytes(), reversed(Uint32ToBitBlock(x))) {
+			t.Fatalf("the BitBlock obtained by calling Uint32ToBitBlockBE(%d) = %s does not have the bytes of Uint32ToBitBlock(%d) in reverse order", x, bitBlock.ToBinaryString(), x)
+		}
+		if x2 := BitBlockToUint32BE(bitBlock); x != x2 {
+			t.Fatalf("the uint32 obtained by calling BitBlockToUint32BE(%s) = %d is wrong, want %d", bitBlock.ToBinaryString(), x2, x)
+		}
+		if !Int32ToBitBlockBE(int32(x)).Equal(bitBlock) || BitBlockToInt32BE(bitBlock) != int32(x) {
+			t.Fatalf("wrong conversion between the int32 %d and a BitBlock in big endian format", int32(x))
+		}
+	}
+
+	// Test the functions Uint64ToBitBlockBE and BitBlockToUint64BE,
+	// and their signed variants.
+	uint64Numbers := []uint64{0, 1, 255, 256, 65536, 4294967296, 1 << 63, 18446744073709551615, 9223372036854775807, 1234567890123456789, 11259375}
+	for _, x := range uint64Numbers {
+		bitBlock := Uint64ToBitBlockBE(x)
+		if !equalBytes(bitBlock.ToBytes(), reversed(Uint64ToBitBlock(x))) {
+			t.Fatalf("the BitBlock obtained by calling Uint64ToBitBlockBE(%d) = %s does not have the bytes of Uint64ToBitBlock(%d) in reverse order", x, bitBlock.ToBinaryString(), x)
+		}
+		if x2 := BitBlockToUint64BE(bitBlock); x != x2 {
+			t.Fatalf("the uint64 obtained by calling BitBlockToUint64BE(%s) = %d is wrong, want %d", bitBlock.ToBinaryString(), x2, x)
+		}
+		if !Int64ToBitBlockBE(int64(x)).Equal(bitBlock) || BitBlockToInt64BE(bitBlock) != int64(x) {
+			t.Fatalf("wrong conversion between the int64 %d and a BitBlock in big endian format", int64(x))
+		}
+	}
+
+	// Test that the conversions to integers panic with wrong sizes.
+	conversions := map[string]func(*BitBlock){
+		"BitBlockToUint16BE": func(b *BitBlock) { BitBlockToUint16BE(b) },
+		"BitBlockToUint32BE": func(b *BitBlock) { BitBlockToUint32BE(b) },
+		"BitBlockToUint64BE": func(b *BitBlock) { BitBlockToUint64BE(b) },
+		"BitBlockToInt16BE": func(b *BitBlock) { BitBlockToInt16BE(b) },
+		"BitBlockToInt32BE": func(b *BitBlock) { BitBlockToInt32BE(b) },
+		"BitBlockToInt64BE": func(b *BitBlock) { BitBlockToInt64BE(b) },
+	}
+	for name, conversion := range conversions {
+		for _, size := range []int{0, 8, 15, 17, 31, 33, 63, 65} {
+			func() {
+				defer func() {
+					panicMessage := recover()
+					if panicMessage == nil {
+						t.Fatalf("the call to %s(bitBlock) with bitBlock.Size() = %d did not panic", name, size)
+					}
+				}()
+				conversion(NewZeroBitBlock(size))
+			}()
+		}
+	}
+}
+
+// Test the functions UintToBitBlockN and BitBlockToUintN.
+func TestVariableWidthConversionBetweenIntegerNumbersAndBitBlocks(t *testing.T) {
+	type Test struct { id string; x uint64; n int; block string; result uint64 }
+
+	tests := []Test{
+		Test{ id: "0000", x: 5, n: 3, block: "101", result: 5 },
+		Test{ id: "0001", x: 13, n: 3, block: "101", result: 5 },
+		Test{ id: "0002", x: 6, n: 5, block: "01100", result: 6 },
+		Test{ id: "0003", x: 0xFFFF, n: 0, block: "", result: 0 },
+		Test{ id: "0004", x: 1, n: 1, block: "1", result: 1 },
+		Test{ id: "0005", x: 0x1FF, n: 8, block: "11111111", result: 0xFF },
+		Test{ id: "0006", x: 0x1FF, n: 10, block: "1111111110", result: 0x1FF },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock := UintToBitBlockN(test.x, test.n)
+			if ok := checkBitBlockBinaryString(t, bitBlock, test.block); !ok {
+				t.Fatalf("wrong answer for UintToBitBlockN(%d, %d), want %q", test.x, test.n, test.block)
+			}
+			if result := BitBlockToUintN(bitBlock); result != test.result {
+				t.Fatalf("got BitBlockToUintN(%q) = %d, want %d", test.block, result, test.result)
+			}
+		})
+	}
+
+	// Test that the conversions agree with the fixed-width ones.
+	uint64Numbers := []uint64{0, 1, 255, 256, 65536, 4294967296, 1 << 63, 18446744073709551615, 1234567890123456789, 11259375}
+	for _, x := range uint64Numbers {
+		for n := 0; n <= 64; n++ {
+			bitBlock := UintToBitBlockN(x, n)
+			if ok := checkBitBlockBinaryString(t, bitBlock, Uint64ToBitBlock(x).GetSubBlock(0, n).ToBinaryString()); !ok {
+				t.Fatalf("wrong answer for UintToBitBlockN(%d, %d)", x, n)
+			}
+			if result, want := BitBlockToUintN(bitBlock), x & FirstBitsSet1Uint64(n); result != want {
+				t.Fatalf("got BitBlockToUintN(UintToBitBlockN(%d, %d)) = %d, want %d", x, n, result, want)
+			}
+		}
+	}
+
+	// Test that the conversions panic with invalid sizes.
+	for _, n := range []int{-1, 65} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to UintToBitBlockN(1, %d) did not panic", n)
+				}
+			}()
+			UintToBitBlockN(1, n)
+		}()
+	}
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to BitBlockToUintN(bitBlock) with bitBlock.Size() = 65 did not panic")
+			}
+		}()
+		BitBlockToUintN(NewZeroBitBlock(65))
+	}()
+}
+
+// Test the conversions between floating-point numbers and BitBlocks.
+func TestConversionBetweenFloatingPointNumbersAndBitBlocks(t *testing.T) {
+	// Test the functions Float32ToBitBlock and BitBlockToFloat32,
+	// comparing the bit patterns so that NaN and -0 are checked too.
+	float32Numbers := []float32{0, float32(math.Copysign(0, -1)), 1, -1, 3.14159, -2.5e-38, math.MaxFloat32, math.SmallestNonzeroFloat32, float32(math.Inf(1)), float32(math.Inf(-1)), float32(math.NaN()), math.Float32frombits(0x7FC00001)}
+	for _, f := range float32Numbers {
+		bitBlock := Float32ToBitBlock(f)
+		if ok := checkBitBlockBinaryString(t, bitBlock, Uint32ToBitBlock(math.Float32bits(f)).ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock for Float32ToBitBlock(%g)", f)
+		}
+		if f2 := BitBlockToFloat32(bitBlock); math.Float32bits(f2) != math.Float32bits(f) {
+			t.Fatalf("got BitBlockToFloat32(Float32ToBitBlock(%g)) with bits %#x, want bits %#x", f, math.Float32bits(f2), math.Float32bits(f))
+		}
+	}
+
+	// Test the functions Float64ToBitBlock and BitBlockToFloat64.
+	float64Numbers := []float64{0, math.Copysign(0, -1), 1, -1, math.Pi, -2.5e-300, math.MaxFloat64, math.SmallestNonzeroFloat64, math.Inf(1), math.Inf(-1), math.NaN(), math.Float64frombits(0x7FF8000000000001)}
+	for _, f := range float64Numbers {
+		bitBlock := Float64ToBitBlock(f)
+		if ok := checkBitBlockBinaryString(t, bitBlock, Uint64ToBitBlock(math.Float64bits(f)).ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock for Float64ToBitBlock(%g)", f)
+		}
+		if f2 := BitBlockToFloat64(bitBlock); math.Float64bits(f2) != math.Float64bits(f) {
+			t.Fatalf("got BitBlockToFloat64(Float64ToBitBlock(%g)) with bits %#x, want bits %#x", f, math.Float64bits(f2), math.Float64bits(f))
+		}
+	}
+
+	// The sign bit is the last bit and, for negative zero, the only bit
+	// set to 1.
+	if bitBlock := Float64ToBitBlock(math.Copysign(0, -1)); !bitBlock.Get(63) || bitBlock.PopCount() != 1 {
+		t.Fatalf("got Float64ToBitBlock(-0) = %s, want only the bit 63 set to 1", bitBlock.ToBinaryString())
+	}
+
+	// Test that the conversions to floating-point numbers panic with
+	// wrong sizes.
+	for _, size := range []int{0, 16, 31, 33, 64} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to BitBlockToFloat32(bitBlock) with bitBlock.Size() = %d did not panic", size)
+				}
+			}()
+			BitBlockToFloat32(NewZeroBitBlock(size))
+		}()
+	}
+	for _, size := range []int{0, 32, 63, 65, 128} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to BitBlockToFloat64(bitBlock) with bitBlock.Size() = %d did not panic", size)
+				}
+			}()
+			BitBlockToFloat64(NewZeroBitBlock(size))
+		}()
+	}
+}
+
 // Test the functions to set the first or last bits of an integer
 // number to 1 and the rest to 0:
 // - FirstBitsSet1Uint8, FirstBitsSet1Uint32, FirstBitsSet1Uint64.
@@ -1183,6 +1439,2657 @@ func TestSetBitsInNumbers(t *testing.T) {
 	}
 }
 
+// Test the SetBitIndicesU32() method and the U32IndicesToBitBlock()
+// function.
+func TestSetBitIndicesU32(t *testing.T) {
+	// A moderately sparse BitBlock, with bits set to 1 in some
+	// positions across several bytes.
+	size := 300
+	bitBlock := NewZeroBitBlock(size)
+	correctIndices := []uint32{0, 7, 8, 31, 64, 65, 100, 159, 160, 255, 256, 299}
+	for _, index := range correctIndices {
+		bitBlock.Set1(int(index))
+	}
+
+	// Test that the indices are the positions of the bits set to 1
+	// in increasing order.
+	indices := bitBlock.SetBitIndicesU32()
+	if len(indices) != len(correctIndices) {
+		t.Fatalf("got len(indices) = %d, want len(indices) = %d", len(indices), len(correctIndices))
+	}
+	for i := 0; i < len(indices); i++ {
+		if indices[i] != correctIndices[i] {
+			t.Fatalf("got indices[%d] = %d, want indices[%d] = %d", i, indices[i], i, correctIndices[i])
+		}
+	}
+
+	// Test that the BitBlock is recovered from the indices.
+	bitBlock2 := U32IndicesToBitBlock(indices, size)
+	if ok := checkBitBlockBinaryString(t, bitBlock2, bitBlock.ToBinaryString()); !ok {
+		t.Fatalf("the BitBlock obtained by calling U32IndicesToBitBlock(indices, %d) is different from the original BitBlock", size)
+	}
+
+	// Test that an empty BitBlock has no indices.
+	if indices := NewZeroBitBlock(0).SetBitIndicesU32(); len(indices) != 0 {
+		t.Fatalf("got len(indices) = %d for an empty BitBlock, want len(indices) = 0", len(indices))
+	}
+
+	// Test that U32IndicesToBitBlock() panics if an index is out of range
+	// or the size is negative.
+	for _, test := range []struct{ indices []uint32; size int }{ {[]uint32{3, 10}, 10}, {[]uint32{}, -1}, {[]uint32{4294967295}, 64} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to U32IndicesToBitBlock(%v, %d) did not panic", test.indices, test.size)
+				}
+			}()
+			U32IndicesToBitBlock(test.indices, test.size)
+		}()
+	}
+}
+
+// Test the CommonPrefix() function.
+func TestCommonPrefix(t *testing.T) {
+	type Test struct { id string; blocks []string; prefix string }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", blocks: []string{"1011001", "10110111", "1011010", "10110"}, prefix: "10110" },
+		Test{ id: "0001", blocks: []string{"10110010101", "10110111010101", "101100"}, prefix: "10110" },
+		Test{ id: "0002", blocks: []string{"10110", "0011", "10111"}, prefix: "" },
+		Test{ id: "0003", blocks: []string{"1111", "", "1111"}, prefix: "" },
+		Test{ id: "0004", blocks: []string{"0101110101"}, prefix: "0101110101" },
+		Test{ id: "0005", blocks: []string{"010101010110101", "010101010110101"}, prefix: "010101010110101" },
+		Test{ id: "0006", blocks: []string{}, prefix: "" },
+	}
+
+	for _, test := range tests {
+		blocks := make([]*BitBlock, len(test.blocks))
+		for i, s := range test.blocks {
+			blocks[i] = binaryStringToBitBlock(s)
+		}
+		t.Run(test.id, func(t *testing.T) {
+			prefix := CommonPrefix(blocks)
+			if ok := checkBitBlockBinaryString(t, prefix, test.prefix); !ok {
+				t.Fatalf("wrong answer for CommonPrefix(%v), want %q", test.blocks, test.prefix)
+			}
+
+			// Test that stripping the prefix leaves the rest of each BitBlock.
+			for i, bitBlock := range blocks {
+				stripped := bitBlock.RemoveFirstBits(prefix.Size())
+				if ok := checkBitBlockBinaryString(t, stripped, test.blocks[i][len(test.prefix):]); !ok {
+					t.Fatalf("wrong answer after stripping the prefix %q from %q", test.prefix, test.blocks[i])
+				}
+			}
+		})
+	}
+}
+
+// Test the OrReduce() and AndReduce() methods.
+func TestReduce(t *testing.T) {
+	type Test struct { id string; s string; or bool; and bool }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "", or: false, and: true },
+		Test{ id: "0001", s: "0000000000000", or: false, and: false },
+		Test{ id: "0002", s: "0000000000001", or: true, and: false },
+		Test{ id: "0003", s: "1000000000000", or: true, and: false },
+		Test{ id: "0004", s: "1111111111111", or: true, and: true },
+		Test{ id: "0005", s: "1111111101111", or: true, and: false },
+		Test{ id: "0006", s: "11111111", or: true, and: true },
+		Test{ id: "0007", s: "0", or: false, and: false },
+		Test{ id: "0008", s: "1", or: true, and: true },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			if or := bitBlock.OrReduce(); or != test.or {
+				t.Fatalf("got OrReduce() = %t for %q, want %t", or, test.s, test.or)
+			}
+			if and := bitBlock.AndReduce(); and != test.and {
+				t.Fatalf("got AndReduce() = %t for %q, want %t", and, test.s, test.and)
+			}
+		})
+	}
+}
+
+// Test the BitsToToggleForDensity() method.
+func TestBitsToToggleForDensity(t *testing.T) {
+	type Test struct { id string; s string; target float64; toggleOn int; toggleOff int }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "1100000000", target: 0.5, toggleOn: 3, toggleOff: 0 },
+		Test{ id: "0001", s: "1100000000", target: 0.1, toggleOn: 0, toggleOff: 1 },
+		Test{ id: "0002", s: "1100000000", target: 0.2, toggleOn: 0, toggleOff: 0 },
+		Test{ id: "0003", s: "1101101111", target: 0, toggleOn: 0, toggleOff: 8 },
+		Test{ id: "0004", s: "1101101111", target: 1, toggleOn: 2, toggleOff: 0 },
+		Test{ id: "0005", s: "0000", target: 0.3, toggleOn: 1, toggleOff: 0 },
+		Test{ id: "0006", s: "", target: 0.7, toggleOn: 0, toggleOff: 0 },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			toggleOn, toggleOff := bitBlock.BitsToToggleForDensity(test.target)
+			if toggleOn != test.toggleOn || toggleOff != test.toggleOff {
+				t.Fatalf("got BitsToToggleForDensity(%g) = (%d, %d) for %q, want (%d, %d)", test.target, toggleOn, toggleOff, test.s, test.toggleOn, test.toggleOff)
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock, test.s); !ok {
+				t.Fatalf("the call to BitsToToggleForDensity(%g) modified the BitBlock", test.target)
+			}
+		})
+	}
+
+	// Test that BitsToToggleForDensity() panics if the target is out of range.
+	bitBlock := binaryStringToBitBlock("0110")
+	for _, target := range []float64{-0.1, 1.5, -3, math.NaN()} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to BitsToToggleForDensity(%g) did not panic", target)
+				}
+			}()
+			bitBlock.BitsToToggleForDensity(target)
+		}()
+	}
+}
+
+// Test the CenterOfMass() method.
+func TestCenterOfMass(t *testing.T) {
+	type Test struct { id string; s string; center float64 }
+
+	// Test cases.
+	tests := []Test{
+		// Symmetric patterns, the center is the midpoint.
+		Test{ id: "0000", s: "1000000001", center: 4.5 },
+		Test{ id: "0001", s: "0011001100", center: 4.5 },
+		Test{ id: "0002", s: "111111111", center: 4 },
+		// Asymmetric patterns.
+		Test{ id: "0003", s: "1101000000", center: 4.0 / 3.0 },
+		Test{ id: "0004", s: "0000000000000001", center: 15 },
+		Test{ id: "0005", s: "1000000000000001000", center: 7.5 },
+		// No bits set to 1.
+		Test{ id: "0006", s: "0000000000", center: math.NaN() },
+		Test{ id: "0007", s: "", center: math.NaN() },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			center := bitBlock.CenterOfMass()
+			if math.IsNaN(test.center) {
+				if !math.IsNaN(center) {
+					t.Fatalf("got CenterOfMass() = %g for %q, want NaN", center, test.s)
+				}
+			} else if math.Abs(center - test.center) > 1e-9 {
+				t.Fatalf("got CenterOfMass() = %g for %q, want %g", center, test.s, test.center)
+			}
+		})
+	}
+}
+
+// Test the CompactOnes() method.
+func TestCompactOnes(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		count := 0
+		for pos := 0; pos < size; pos++ {
+			if bitBlock.Get(pos) {
+				count++
+			}
+		}
+
+		// The compacted BitBlock has the first count bits set to 1
+		// and the rest set to 0.
+		bools := make([]bool, size)
+		for pos := 0; pos < count; pos++ {
+			bools[pos] = true
+		}
+		compacted := bitBlock.CompactOnes()
+		if ok := checkBitBlockValues(t, compacted, bools); !ok {
+			t.Fatalf("wrong answer for CompactOnes() on a BitBlock of size %d with %d bits set to 1", size, count)
+		}
+		if ok := checkPaddingBits(t, compacted); !ok {
+			t.Fatalf("the call to CompactOnes() on a BitBlock of size %d returned a BitBlock with some padding bits set to true", size)
+		}
+	}
+}
+
+// Test the ZeroStuff() method.
+func TestZeroStuff(t *testing.T) {
+	type Test struct { id string; s string; every int; stuffed string }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "111111111", every: 3, stuffed: "111011101110" },
+		Test{ id: "0001", s: "1011011", every: 3, stuffed: "101010101" },
+		Test{ id: "0002", s: "11", every: 3, stuffed: "11" },
+		Test{ id: "0003", s: "1101", every: 1, stuffed: "10100010" },
+		Test{ id: "0004", s: "11111111111111111", every: 5, stuffed: "11111011111011111011" },
+		Test{ id: "0005", s: "", every: 2, stuffed: "" },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			if ok := checkBitBlockBinaryString(t, bitBlock.ZeroStuff(test.every), test.stuffed); !ok {
+				t.Fatalf("wrong answer for ZeroStuff(%d) on %q, want %q", test.every, test.s, test.stuffed)
+			}
+		})
+	}
+
+	// Test that ZeroStuff() panics if every < 1.
+	bitBlock := binaryStringToBitBlock("0110")
+	for _, every := range []int{0, -1, -8} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to ZeroStuff(%d) did not panic", every)
+				}
+			}()
+			bitBlock.ZeroStuff(every)
+		}()
+	}
+}
+
+// Test the UnstuffZeros() method.
+func TestUnstuffZeros(t *testing.T) {
+	// Test that the BitBlocks are recovered after stuffing them.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for _, every := range []int{1, 2, 3, 5, 7, 8, 13, 200} {
+			bitBlock2, ok := bitBlock.ZeroStuff(every).UnstuffZeros(every)
+			if !ok {
+				t.Fatalf("got ok = false after calling UnstuffZeros(%d) on ZeroStuff(%d) for a BitBlock of size %d, want ok = true", every, every, size)
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock2, bitBlock.ToBinaryString()); !ok {
+				t.Fatalf("the BitBlock of size %d is different after calling ZeroStuff(%d) and UnstuffZeros(%d)", size, every, every)
+			}
+		}
+	}
+
+	// Test that corrupted BitBlocks are detected, where some of the
+	// bits at every (every+1)-th position is set to 1.
+	type Test struct { id string; s string; every int; unstuffed string; ok bool }
+	tests := []Test{
+		Test{ id: "0000", s: "111011101110", every: 3, unstuffed: "111111111", ok: true },
+		Test{ id: "0001", s: "111011111110", every: 3, unstuffed: "111111111", ok: false },
+		Test{ id: "0002", s: "111011101111", every: 3, unstuffed: "111111111", ok: false },
+		Test{ id: "0003", s: "0101", every: 1, unstuffed: "00", ok: false },
+		Test{ id: "0004", s: "10100", every: 1, unstuffed: "110", ok: true },
+	}
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock2, ok := bitBlock.UnstuffZeros(test.every)
+			if ok != test.ok {
+				t.Fatalf("got ok = %t after calling UnstuffZeros(%d) on %q, want ok = %t", ok, test.every, test.s, test.ok)
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock2, test.unstuffed); !ok {
+				t.Fatalf("wrong answer for UnstuffZeros(%d) on %q, want %q", test.every, test.s, test.unstuffed)
+			}
+		})
+	}
+
+	// Test that UnstuffZeros() panics if every < 1.
+	bitBlock := binaryStringToBitBlock("0110")
+	for _, every := range []int{0, -1, -8} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to UnstuffZeros(%d) did not panic", every)
+				}
+			}()
+			bitBlock.UnstuffZeros(every)
+		}()
+	}
+}
+
+// Test the SelectZero() method.
+func TestSelectZero(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+
+		// The positions of the bits set to 0, obtained with Get().
+		positions := []int{}
+		for pos := 0; pos < size; pos++ {
+			if !bitBlock.Get(pos) {
+				positions = append(positions, pos)
+			}
+		}
+		for k, pos := range positions {
+			if pos2 := bitBlock.SelectZero(k); pos2 != pos {
+				t.Fatalf("got SelectZero(%d) = %d on a BitBlock of size %d, want SelectZero(%d) = %d", k, pos2, size, k, pos)
+			}
+		}
+		for _, k := range []int{len(positions), len(positions) + 1, len(positions) + 8, -1} {
+			if pos := bitBlock.SelectZero(k); pos != -1 {
+				t.Fatalf("got SelectZero(%d) = %d on a BitBlock of size %d with %d bits set to 0, want SelectZero(%d) = -1", k, pos, size, len(positions), k)
+			}
+		}
+	}
+
+	// Test that a BitBlock with all bits set to 1 has no bits set to 0,
+	// even if its last byte has padding bits.
+	for _, size := range []int{0, 1, 7, 8, 9, 135} {
+		bitBlock := BytesToBitBlock([]byte{255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255, 255}, size)
+		for k := 0; k < 10; k++ {
+			if pos := bitBlock.SelectZero(k); pos != -1 {
+				t.Fatalf("got SelectZero(%d) = %d on a BitBlock of size %d with all bits set to 1, want SelectZero(%d) = -1", k, pos, size, k)
+			}
+		}
+	}
+}
+
+// Test the RankZero() method.
+func TestRankZero(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+
+		// Test that the bits set to 0 and the bits set to 1 before each
+		// position add up to the position.
+		ones := 0
+		for pos := 0; pos <= size; pos++ {
+			if rank := bitBlock.RankZero(pos); ones + rank != pos {
+				t.Fatalf("got RankZero(%d) = %d on a BitBlock of size %d with %d bits set to 1 before that position, want RankZero(%d) = %d", pos, rank, size, ones, pos, pos - ones)
+			}
+			if pos < size && bitBlock.Get(pos) {
+				ones++
+			}
+		}
+
+		// Test that RankZero() is the inverse of SelectZero().
+		for k := 0; bitBlock.SelectZero(k) != -1; k++ {
+			if rank := bitBlock.RankZero(bitBlock.SelectZero(k)); rank != k {
+				t.Fatalf("got RankZero(SelectZero(%d)) = %d on a BitBlock of size %d, want %d", k, rank, size, k)
+			}
+		}
+
+		// Test that RankZero() panics for invalid positions.
+		for _, pos := range []int{-1, -8, size + 1, size + 9} {
+			func() {
+				defer func() {
+					panicMessage := recover()
+					if panicMessage == nil {
+						t.Fatalf("the call to RankZero(%d) on a BitBlock of size %d did not panic", pos, size)
+					}
+				}()
+				bitBlock.RankZero(pos)
+			}()
+		}
+	}
+}
+
+// Test the AppendBytes() method.
+func TestAppendBytes(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	buffer := make([]byte, 0, 64)
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		prefix := []byte{1, 2, 3}
+		bytes2 := bitBlock.AppendBytes(prefix)
+		correct := append([]byte{1, 2, 3}, bitBlock.ToBytes()...)
+		if len(bytes2) != len(correct) {
+			t.Fatalf("got len(AppendBytes(dst)) = %d on a BitBlock of size %d, want %d", len(bytes2), size, len(correct))
+		}
+		for i := range correct {
+			if bytes2[i] != correct[i] {
+				t.Fatalf("got AppendBytes(dst)[%d] = %d on a BitBlock of size %d, want %d", i, bytes2[i], size, correct[i])
+			}
+		}
+
+		// Test that a buffer with enough capacity is reused.
+		buffer = bitBlock.AppendBytes(buffer[:0])
+		if cap(buffer) != 64 {
+			t.Fatalf("got cap(buffer) = %d after AppendBytes(buffer[:0]) on a BitBlock of size %d, want the buffer to be reused with cap(buffer) = 64", cap(buffer), size)
+		}
+		for i, b := range bitBlock.ToBytes() {
+			if buffer[i] != b {
+				t.Fatalf("got buffer[%d] = %d after AppendBytes(buffer[:0]) on a BitBlock of size %d, want %d", i, buffer[i], size, b)
+			}
+		}
+	}
+}
+
+// Test the EqualConstantTime() function.
+func TestEqualConstantTime(t *testing.T) {
+	type Test struct { id string; a string; b string; equal bool }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", a: "", b: "", equal: true },
+		Test{ id: "0001", a: "1011010111", b: "1011010111", equal: true },
+		Test{ id: "0002", a: "1011010111", b: "1011010110", equal: false },
+		Test{ id: "0003", a: "0011010111", b: "1011010111", equal: false },
+		Test{ id: "0004", a: "101101011", b: "1011010110", equal: false },
+		Test{ id: "0005", a: "10110101", b: "101101010", equal: false },
+		Test{ id: "0006", a: "0000", b: "", equal: false },
+	}
+
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			if equal := EqualConstantTime(a, b); equal != test.equal {
+				t.Fatalf("got EqualConstantTime(%q, %q) = %t, want %t", test.a, test.b, equal, test.equal)
+			}
+			if equal := EqualConstantTime(b, a); equal != test.equal {
+				t.Fatalf("got EqualConstantTime(%q, %q) = %t, want %t", test.b, test.a, equal, test.equal)
+			}
+		})
+	}
+
+	// Test BitBlocks built from the same bytes, which are equal only if
+	// they have the same size.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size1 := 0; size1 <= 8 * len(bytes); size1++ {
+		for size2 := 0; size2 <= 8 * len(bytes); size2++ {
+			a, b := BytesToBitBlock(bytes, size1), BytesToBitBlock(bytes, size2)
+			if equal := EqualConstantTime(a, b); equal != (size1 == size2) {
+				t.Fatalf("got EqualConstantTime(a, b) = %t for BitBlocks of sizes %d and %d built from the same bytes, want %t", equal, size1, size2, size1 == size2)
+			}
+		}
+	}
+}
+
+// Test the Not() and Complement() methods.
+func TestNot(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		s := bitBlock.ToBinaryString()
+		flipped := make([]byte, size)
+		for i := 0; i < size; i++ {
+			flipped[i] = '0' + '1' - s[i]
+		}
+
+		// Test Complement(), which must not modify the original BitBlock.
+		complement := bitBlock.Complement()
+		if ok := checkBitBlockBinaryString(t, complement, string(flipped)); !ok {
+			t.Fatalf("wrong answer for Complement() on a BitBlock of size %d", size)
+		}
+		if ok := checkBitBlockBinaryString(t, bitBlock, s); !ok {
+			t.Fatalf("the call to Complement() modified the BitBlock of size %d", size)
+		}
+
+		// Test that calling Not() twice recovers the original BitBlock.
+		bitBlock.Not()
+		if ok := checkBitBlockBinaryString(t, bitBlock, string(flipped)); !ok {
+			t.Fatalf("wrong answer for Not() on a BitBlock of size %d", size)
+		}
+		bitBlock.Not()
+		if ok := checkBitBlockBinaryString(t, bitBlock, s); !ok {
+			t.Fatalf("the BitBlock of size %d is different after calling Not() twice", size)
+		}
+	}
+}
+
+// Test the AndNot() function.
+func TestAndNot(t *testing.T) {
+	type Test struct { id string; a string; b string; result string }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", a: "1100", b: "1010", result: "0100" },
+		Test{ id: "0001", a: "1111111111", b: "0000000000", result: "1111111111" },
+		Test{ id: "0002", a: "1111111111", b: "1111111111", result: "0000000000" },
+		Test{ id: "0003", a: "101101110111011", b: "011111000001101", result: "100000110110010" },
+		Test{ id: "0004", a: "", b: "", result: "" },
+	}
+
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			if ok := checkBitBlockBinaryString(t, AndNot(a, b), test.result); !ok {
+				t.Fatalf("wrong answer for AndNot(%q, %q), want %q", test.a, test.b, test.result)
+			}
+		})
+	}
+
+	// Test against a comparison bit by bit.
+	bytes1 := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	for size := 0; size <= 8 * len(bytes1); size++ {
+		a, b := BytesToBitBlock(bytes1, size), BytesToBitBlock(bytes2, size)
+		bools := make([]bool, size)
+		for i := 0; i < size; i++ {
+			bools[i] = a.Get(i) && !b.Get(i)
+		}
+		result := AndNot(a, b)
+		if ok := checkBitBlockValues(t, result, bools); !ok {
+			t.Fatalf("wrong answer for AndNot(a, b) on BitBlocks of size %d", size)
+		}
+		if ok := checkPaddingBits(t, result); !ok {
+			t.Fatalf("the call to AndNot(a, b) on BitBlocks of size %d returned a BitBlock with some padding bits set to true", size)
+		}
+	}
+
+	// Test that AndNot() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to AndNot(a, b) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		AndNot(NewZeroBitBlock(8), NewZeroBitBlock(9))
+	}()
+}
+
+// Test the CopyIf() method.
+func TestCopyIf(t *testing.T) {
+	bytes1 := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	for size := 0; size <= 8 * len(bytes1); size++ {
+		src := BytesToBitBlock(bytes2, size)
+
+		// The bits are not copied if the condition is false.
+		bitBlock := BytesToBitBlock(bytes1, size)
+		bitBlock.CopyIf(false, src)
+		if ok := checkBitBlockBinaryString(t, bitBlock, BytesToBitBlock(bytes1, size).ToBinaryString()); !ok {
+			t.Fatalf("the call to CopyIf(false, src) modified the BitBlock of size %d", size)
+		}
+
+		// The bits are copied if the condition is true.
+		bitBlock.CopyIf(true, src)
+		if ok := checkBitBlockBinaryString(t, bitBlock, src.ToBinaryString()); !ok {
+			t.Fatalf("the call to CopyIf(true, src) did not copy src into the BitBlock of size %d", size)
+		}
+	}
+
+	// Test that CopyIf() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to CopyIf(true, src) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		NewZeroBitBlock(8).CopyIf(true, NewZeroBitBlock(9))
+	}()
+}
+
+// Test the AndWith(), OrWith() and XorWith() methods.
+func TestLogicalOperationsInPlace(t *testing.T) {
+	type Operation struct { name string; apply func(a *BitBlock, b *BitBlock); eval func(x bool, y bool) bool }
+	operations := []Operation{
+		Operation{ "AndWith", func(a *BitBlock, b *BitBlock) { a.AndWith(b) }, func(x bool, y bool) bool { return x && y } },
+		Operation{ "OrWith", func(a *BitBlock, b *BitBlock) { a.OrWith(b) }, func(x bool, y bool) bool { return x || y } },
+		Operation{ "XorWith", func(a *BitBlock, b *BitBlock) { a.XorWith(b) }, func(x bool, y bool) bool { return x != y } },
+	}
+
+	bytes1 := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	for _, operation := range operations {
+		t.Run(operation.name, func(t *testing.T) {
+			for size := 0; size <= 8 * len(bytes1); size++ {
+				a, b := BytesToBitBlock(bytes1, size), BytesToBitBlock(bytes2, size)
+				bools := make([]bool, size)
+				for i := 0; i < size; i++ {
+					bools[i] = operation.eval(a.Get(i), b.Get(i))
+				}
+				operation.apply(a, b)
+				if ok := checkBitBlockValues(t, a, bools); !ok {
+					t.Fatalf("wrong answer for %s() on BitBlocks of size %d", operation.name, size)
+				}
+				if ok := checkPaddingBits(t, a); !ok {
+					t.Fatalf("the call to %s() on BitBlocks of size %d set some padding bits to true", operation.name, size)
+				}
+				if ok := checkBitBlockBinaryString(t, b, BytesToBitBlock(bytes2, size).ToBinaryString()); !ok {
+					t.Fatalf("the call to %s() on BitBlocks of size %d modified the argument", operation.name, size)
+				}
+			}
+
+			// Test that the method panics if the sizes are different.
+			func() {
+				defer func() {
+					panicMessage := recover()
+					if panicMessage == nil {
+						t.Fatalf("the call to %s() on BitBlocks of sizes 8 and 9 did not panic", operation.name)
+					}
+				}()
+				operation.apply(NewZeroBitBlock(8), NewZeroBitBlock(9))
+			}()
+		})
+	}
+}
+
+// Benchmark the XorWith() method on a BitBlock of 1 MB, modifying
+// the BitBlock in place.
+func BenchmarkXorWithInPlace(b *testing.B) {
+	size := 8 << 20
+	bitBlock, other := NewZeroBitBlock(size), NewZeroBitBlock(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitBlock.XorWith(other)
+	}
+}
+
+// Benchmark the XOR of two BitBlocks of 1 MB allocating a new
+// BitBlock for the result, as an allocating operation would do.
+func BenchmarkXorWithAllocating(b *testing.B) {
+	size := 8 << 20
+	bitBlock, other := NewZeroBitBlock(size), NewZeroBitBlock(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result := bitBlock.Clone()
+		result.XorWith(other)
+	}
+}
+
+// Test the ShiftLeft() method.
+func TestShiftLeft(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		s := bitBlock.ToBinaryString()
+		for k := 0; k <= size + 10; k++ {
+			// The bit at position i moves to position i+k.
+			correct := make([]byte, size)
+			for i := 0; i < size; i++ {
+				if i < k {
+					correct[i] = '0'
+				} else {
+					correct[i] = s[i-k]
+				}
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock.ShiftLeft(k), string(correct)); !ok {
+				t.Fatalf("wrong answer for ShiftLeft(%d) on a BitBlock of size %d", k, size)
+			}
+		}
+	}
+
+	// Test that ShiftLeft() panics if k < 0.
+	bitBlock := binaryStringToBitBlock("0110")
+	for _, k := range []int{-1, -8} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to ShiftLeft(%d) did not panic", k)
+				}
+			}()
+			bitBlock.ShiftLeft(k)
+		}()
+	}
+}
+
+// Test the ShiftRight() method.
+func TestShiftRight(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		s := bitBlock.ToBinaryString()
+		for k := 0; k <= size + 10; k++ {
+			// The bit at position i moves to position i-k.
+			correct := make([]byte, size)
+			for i := 0; i < size; i++ {
+				if i + k < size {
+					correct[i] = s[i+k]
+				} else {
+					correct[i] = '0'
+				}
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock.ShiftRight(k), string(correct)); !ok {
+				t.Fatalf("wrong answer for ShiftRight(%d) on a BitBlock of size %d", k, size)
+			}
+
+			// Shifting to the left and then to the right recovers the
+			// BitBlock if its k highest bits are 0.
+			if k <= size {
+				bitBlock2 := bitBlock.RemoveLastBits(k)
+				bitBlock2 = Concatenate(bitBlock2, NewZeroBitBlock(k))
+				if ok := checkBitBlockBinaryString(t, bitBlock2.ShiftLeft(k).ShiftRight(k), bitBlock2.ToBinaryString()); !ok {
+					t.Fatalf("the BitBlock of size %d with the %d highest bits set to 0 is different after calling ShiftLeft(%d) and ShiftRight(%d)", size, k, k, k)
+				}
+			}
+		}
+	}
+
+	// Test that ShiftRight() panics if k < 0.
+	bitBlock := binaryStringToBitBlock("0110")
+	for _, k := range []int{-1, -8} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to ShiftRight(%d) did not panic", k)
+				}
+			}()
+			bitBlock.ShiftRight(k)
+		}()
+	}
+}
+
+// Test the PopCountParallel() method.
+func TestPopCountParallel(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		count := 0
+		for pos := 0; pos < size; pos++ {
+			if bitBlock.Get(pos) {
+				count++
+			}
+		}
+		for _, workers := range []int{1, 2, 3, 5, 13, 20} {
+			if count2 := bitBlock.PopCountParallel(workers); count2 != count {
+				t.Fatalf("got PopCountParallel(%d) = %d on a BitBlock of size %d, want %d", workers, count2, size, count)
+			}
+		}
+	}
+
+	// Test a large BitBlock.
+	size := 1 << 20 + 13
+	bitBlock := NewZeroBitBlock(size)
+	count := 0
+	for pos := 0; pos < size; pos += 7 {
+		bitBlock.Set1(pos)
+		count++
+	}
+	for _, workers := range []int{1, 4, 8, 100} {
+		if count2 := bitBlock.PopCountParallel(workers); count2 != count {
+			t.Fatalf("got PopCountParallel(%d) = %d on a BitBlock of size %d, want %d", workers, count2, size, count)
+		}
+	}
+
+	// Test that PopCountParallel() panics if workers < 1.
+	for _, workers := range []int{0, -1} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to PopCountParallel(%d) did not panic", workers)
+				}
+			}()
+			bitBlock.PopCountParallel(workers)
+		}()
+	}
+}
+
+// Benchmark the PopCountParallel() method with one worker on a
+// BitBlock of 64 MB.
+func BenchmarkPopCountParallel1(b *testing.B) {
+	bitBlock := NewZeroBitBlock(8 << 26)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitBlock.PopCountParallel(1)
+	}
+}
+
+// Benchmark the PopCountParallel() method with eight workers on a
+// BitBlock of 64 MB.
+func BenchmarkPopCountParallel8(b *testing.B) {
+	bitBlock := NewZeroBitBlock(8 << 26)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitBlock.PopCountParallel(8)
+	}
+}
+
+// Test the PopCount() method.
+func TestPopCount(t *testing.T) {
+	r := rand.New(rand.NewSource(1733))
+	for i := 0; i < 500; i++ {
+		size := r.Intn(300)
+		bytes := make([]byte, (size + 7) / 8)
+		r.Read(bytes)
+		bitBlock := BytesToBitBlock(bytes, size)
+		count := 0
+		for pos := 0; pos < size; pos++ {
+			if bitBlock.Get(pos) {
+				count++
+			}
+		}
+		if count2 := bitBlock.PopCount(); count2 != count {
+			t.Fatalf("got PopCount() = %d on a BitBlock of size %d, want %d", count2, size, count)
+		}
+	}
+}
+
+// Test the CountTrailingZeros() and CountLeadingZeros() methods.
+func TestCountZeros(t *testing.T) {
+	type Test struct { id string; s string; trailing int; leading int }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "", trailing: 0, leading: 0 },
+		Test{ id: "0001", s: "0000000000", trailing: 10, leading: 10 },
+		Test{ id: "0002", s: "1000000000", trailing: 0, leading: 9 },
+		Test{ id: "0003", s: "0000000001", trailing: 9, leading: 0 },
+		Test{ id: "0004", s: "00000000000000000001", trailing: 19, leading: 0 },
+		Test{ id: "0005", s: "10000000000000000000", trailing: 0, leading: 19 },
+		Test{ id: "0006", s: "0000000000010100000000000", trailing: 11, leading: 11 },
+		Test{ id: "0007", s: "00000001", trailing: 7, leading: 0 },
+		Test{ id: "0008", s: "1", trailing: 0, leading: 0 },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			if n := bitBlock.CountTrailingZeros(); n != test.trailing {
+				t.Fatalf("got CountTrailingZeros() = %d for %q, want %d", n, test.s, test.trailing)
+			}
+			if n := bitBlock.CountLeadingZeros(); n != test.leading {
+				t.Fatalf("got CountLeadingZeros() = %d for %q, want %d", n, test.s, test.leading)
+			}
+		})
+	}
+
+	// Test BitBlocks whose only bit set to 1 is at each position.
+	for size := 1; size <= 70; size++ {
+		for pos := 0; pos < size; pos++ {
+			bitBlock := NewZeroBitBlock(size)
+			bitBlock.Set1(pos)
+			if n := bitBlock.CountTrailingZeros(); n != pos {
+				t.Fatalf("got CountTrailingZeros() = %d for a BitBlock of size %d with only the bit %d set to 1, want %d", n, size, pos, pos)
+			}
+			if n := bitBlock.CountLeadingZeros(); n != size - 1 - pos {
+				t.Fatalf("got CountLeadingZeros() = %d for a BitBlock of size %d with only the bit %d set to 1, want %d", n, size, pos, size - 1 - pos)
+			}
+		}
+	}
+}
+
+// Test the FindFirstSet() and FindFirstUnset() methods.
+func TestFindFirst(t *testing.T) {
+	// Test BitBlocks in which only one bit is different from the rest,
+	// including the last significant position.
+	for size := 0; size <= 70; size++ {
+		zeros := NewZeroBitBlock(size)
+		ones := NewZeroBitBlock(size)
+		ones.Not()
+		if pos := zeros.FindFirstSet(); pos != -1 {
+			t.Fatalf("got FindFirstSet() = %d for a BitBlock of size %d with all bits set to 0, want -1", pos, size)
+		}
+		if pos := ones.FindFirstUnset(); pos != -1 {
+			t.Fatalf("got FindFirstUnset() = %d for a BitBlock of size %d with all bits set to 1, want -1", pos, size)
+		}
+		for pos := 0; pos < size; pos++ {
+			zeros.Set1(pos)
+			ones.Set0(pos)
+			if pos2 := zeros.FindFirstSet(); pos2 != pos {
+				t.Fatalf("got FindFirstSet() = %d for a BitBlock of size %d with only the bit %d set to 1, want %d", pos2, size, pos, pos)
+			}
+			if pos2 := ones.FindFirstUnset(); pos2 != pos {
+				t.Fatalf("got FindFirstUnset() = %d for a BitBlock of size %d with only the bit %d set to 0, want %d", pos2, size, pos, pos)
+			}
+			if pos2 := zeros.FindFirstUnset(); pos2 != -1 && (pos2 == pos || zeros.Get(pos2)) {
+				t.Fatalf("got FindFirstUnset() = %d for a BitBlock of size %d with only the bit %d set to 1", pos2, size, pos)
+			}
+			zeros.Set0(pos)
+			ones.Set1(pos)
+		}
+	}
+
+	// Test against a scan with Get().
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		first1, first0 := -1, -1
+		for pos := size - 1; pos >= 0; pos-- {
+			if bitBlock.Get(pos) {
+				first1 = pos
+			} else {
+				first0 = pos
+			}
+		}
+		if pos := bitBlock.FindFirstSet(); pos != first1 {
+			t.Fatalf("got FindFirstSet() = %d on a BitBlock of size %d, want %d", pos, size, first1)
+		}
+		if pos := bitBlock.FindFirstUnset(); pos != first0 {
+			t.Fatalf("got FindFirstUnset() = %d on a BitBlock of size %d, want %d", pos, size, first0)
+		}
+	}
+}
+
+// Test the ToUint16Slice() method and the Uint16SliceToBitBlock()
+// function.
+func TestUint16Slice(t *testing.T) {
+	type Test struct { id string; size int; words []uint16 }
+
+	// Test cases, all of them built from the same bytes.
+	bytes := []byte{0x0F, 0x36, 0x7F, 0xC8, 0x00, 0x0F, 0x5F}
+	tests := []Test{
+		Test{ id: "0000", size: 15, words: []uint16{0x360F} },
+		Test{ id: "0001", size: 16, words: []uint16{0x360F} },
+		Test{ id: "0002", size: 17, words: []uint16{0x360F, 0x0001} },
+		Test{ id: "0003", size: 33, words: []uint16{0x360F, 0xC87F, 0x0000} },
+		Test{ id: "0004", size: 52, words: []uint16{0x360F, 0xC87F, 0x0F00, 0x000F} },
+		Test{ id: "0005", size: 0, words: []uint16{} },
+	}
+
+	for _, test := range tests {
+		bitBlock := BytesToBitBlock(bytes, test.size)
+		t.Run(test.id, func(t *testing.T) {
+			words := bitBlock.ToUint16Slice()
+			if len(words) != len(test.words) {
+				t.Fatalf("got len(ToUint16Slice()) = %d for a BitBlock of size %d, want %d", len(words), test.size, len(test.words))
+			}
+			for i := range words {
+				if words[i] != test.words[i] {
+					t.Fatalf("got ToUint16Slice()[%d] = %#04x for a BitBlock of size %d, want %#04x", i, words[i], test.size, test.words[i])
+				}
+			}
+			bitBlock2 := Uint16SliceToBitBlock(words, test.size)
+			if ok := checkBitBlockBinaryString(t, bitBlock2, bitBlock.ToBinaryString()); !ok {
+				t.Fatalf("the BitBlock of size %d is different after calling ToUint16Slice() and Uint16SliceToBitBlock()", test.size)
+			}
+		})
+	}
+
+	// Test that missing words are set to 0 and extra bits are dropped.
+	if ok := checkBitBlockBinaryString(t, Uint16SliceToBitBlock([]uint16{0xFFFF}, 20), "11111111111111110000"); !ok {
+		t.Fatalf("wrong answer for Uint16SliceToBitBlock([0xFFFF], 20)")
+	}
+	if ok := checkBitBlockBinaryString(t, Uint16SliceToBitBlock([]uint16{0xFFFF, 0xFFFF}, 5), "11111"); !ok {
+		t.Fatalf("wrong answer for Uint16SliceToBitBlock([0xFFFF, 0xFFFF], 5)")
+	}
+}
+
+// Test the FindNextSet() method.
+func TestFindNextSet(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 0, 0, 128, 127, 34, 0, 183, 255, 0, 0, 0, 1}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+
+		// Test that iterating with FindNextSet() gives the same positions
+		// as a scan with Get().
+		positions := []int{}
+		for pos := 0; pos < size; pos++ {
+			if bitBlock.Get(pos) {
+				positions = append(positions, pos)
+			}
+		}
+		positions2 := []int{}
+		for i := bitBlock.FindNextSet(0); i != -1; i = bitBlock.FindNextSet(i+1) {
+			positions2 = append(positions2, i)
+		}
+		if len(positions2) != len(positions) {
+			t.Fatalf("got %d positions iterating with FindNextSet() on a BitBlock of size %d, want %d", len(positions2), size, len(positions))
+		}
+		for i := range positions {
+			if positions2[i] != positions[i] {
+				t.Fatalf("got the position %d at index %d iterating with FindNextSet() on a BitBlock of size %d, want %d", positions2[i], i, size, positions[i])
+			}
+		}
+
+		// Test FindNextSet() from every position.
+		for from := 0; from <= size; from++ {
+			next := -1
+			for pos := from; pos < size; pos++ {
+				if bitBlock.Get(pos) {
+					next = pos
+					break
+				}
+			}
+			if pos := bitBlock.FindNextSet(from); pos != next {
+				t.Fatalf("got FindNextSet(%d) = %d on a BitBlock of size %d, want %d", from, pos, size, next)
+			}
+		}
+
+		// Test that FindNextSet() panics for invalid positions.
+		for _, from := range []int{-1, -9, size + 1, size + 8} {
+			func() {
+				defer func() {
+					panicMessage := recover()
+					if panicMessage == nil {
+						t.Fatalf("the call to FindNextSet(%d) on a BitBlock of size %d did not panic", from, size)
+					}
+				}()
+				bitBlock.FindNextSet(from)
+			}()
+		}
+	}
+}
+
+// Test the Equal() method.
+func TestEqual(t *testing.T) {
+	// Test BitBlocks built from the same bytes, which are equal only if
+	// they have the same size.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size1 := 0; size1 <= 8 * len(bytes); size1++ {
+		for size2 := 0; size2 <= 8 * len(bytes); size2++ {
+			a, b := BytesToBitBlock(bytes, size1), BytesToBitBlock(bytes, size2)
+			if equal := a.Equal(b); equal != (size1 == size2) {
+				t.Fatalf("got a.Equal(b) = %t for BitBlocks of sizes %d and %d built from the same bytes, want %t", equal, size1, size2, size1 == size2)
+			}
+		}
+	}
+
+	// Test BitBlocks with the same size that differ in a single bit.
+	for size := 1; size <= 70; size++ {
+		a := BytesToBitBlock(bytes, size)
+		for pos := 0; pos < size; pos++ {
+			b := a.Clone()
+			b.Set(pos, !b.Get(pos))
+			if a.Equal(b) || b.Equal(a) {
+				t.Fatalf("got Equal() = true for BitBlocks of size %d that differ in the bit %d, want false", size, pos)
+			}
+		}
+	}
+
+	// Test that the result matches EqualConstantTime().
+	zeros, zeros2 := NewZeroBitBlock(0), NewZeroBitBlock(0)
+	if !zeros.Equal(zeros2) || !EqualConstantTime(zeros, zeros2) {
+		t.Fatalf("got Equal() = false for empty BitBlocks, want true")
+	}
+}
+
+// Test the KeepLastNSetBits() method.
+func TestKeepLastNSetBits(t *testing.T) {
+	type Test struct { id string; s string; n int; result string }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", s: "1101001011", n: 2, result: "0000000011" },
+		Test{ id: "0001", s: "1101001011", n: 3, result: "0000001011" },
+		Test{ id: "0002", s: "1101001011", n: 6, result: "1101001011" },
+		Test{ id: "0003", s: "1101001011", n: 10, result: "1101001011" },
+		Test{ id: "0004", s: "1101001011", n: 0, result: "0000000000" },
+		Test{ id: "0005", s: "", n: 3, result: "" },
+	}
+
+	for _, test := range tests {
+		bitBlock := binaryStringToBitBlock(test.s)
+		t.Run(test.id, func(t *testing.T) {
+			if ok := checkBitBlockBinaryString(t, bitBlock.KeepLastNSetBits(test.n), test.result); !ok {
+				t.Fatalf("wrong answer for KeepLastNSetBits(%d) on %q, want %q", test.n, test.s, test.result)
+			}
+		})
+	}
+
+	// Test that the kept bits are the highest set bits and that the
+	// number of kept bits is min(n, PopCount()).
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size += 3 {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for n := 0; n <= bitBlock.PopCount() + 2; n++ {
+			kept := bitBlock.KeepLastNSetBits(n)
+			want := n
+			if bitBlock.PopCount() < n {
+				want = bitBlock.PopCount()
+			}
+			if count := kept.PopCount(); count != want {
+				t.Fatalf("got %d bits set to 1 after KeepLastNSetBits(%d) on a BitBlock of size %d, want %d", count, n, size, want)
+			}
+			if !AndNot(kept, bitBlock).Equal(NewZeroBitBlock(size)) {
+				t.Fatalf("the call to KeepLastNSetBits(%d) on a BitBlock of size %d set to 1 some bits that were 0", n, size)
+			}
+			if first := kept.FindFirstSet(); first != -1 && AndNot(bitBlock, kept).FindNextSet(first) != -1 {
+				t.Fatalf("the call to KeepLastNSetBits(%d) on a BitBlock of size %d did not keep the highest bits set to 1", n, size)
+			}
+		}
+	}
+
+	// Test that KeepLastNSetBits() panics if n < 0.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to KeepLastNSetBits(-1) did not panic")
+			}
+		}()
+		NewZeroBitBlock(5).KeepLastNSetBits(-1)
+	}()
+}
+
+// Test the OrAtOffset() function.
+func TestOrAtOffset(t *testing.T) {
+	type Test struct { id string; base string; overlay string; offset int; result string }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", base: "1000000001", overlay: "111", offset: 2, result: "1011100001" },
+		Test{ id: "0001", base: "1000000001", overlay: "101", offset: 0, result: "1010000001" },
+		Test{ id: "0002", base: "1000000001", overlay: "0110", offset: 8, result: "100000000110" },
+		Test{ id: "0003", base: "1000000001", overlay: "11", offset: 15, result: "10000000010000011" },
+		Test{ id: "0004", base: "", overlay: "101", offset: 3, result: "000101" },
+		Test{ id: "0005", base: "1111", overlay: "", offset: 10, result: "1111000000" },
+		Test{ id: "0006", base: "0101", overlay: "", offset: 1, result: "0101" },
+	}
+
+	for _, test := range tests {
+		base, overlay := binaryStringToBitBlock(test.base), binaryStringToBitBlock(test.overlay)
+		t.Run(test.id, func(t *testing.T) {
+			if ok := checkBitBlockBinaryString(t, OrAtOffset(base, overlay, test.offset), test.result); !ok {
+				t.Fatalf("wrong answer for OrAtOffset(%q, %q, %d), want %q", test.base, test.overlay, test.offset, test.result)
+			}
+			if ok := checkBitBlockBinaryString(t, base, test.base); !ok {
+				t.Fatalf("the call to OrAtOffset(%q, %q, %d) modified the base", test.base, test.overlay, test.offset)
+			}
+		})
+	}
+
+	// Test that OrAtOffset() panics if offset < 0.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to OrAtOffset(base, overlay, -1) did not panic")
+			}
+		}()
+		OrAtOffset(NewZeroBitBlock(5), NewZeroBitBlock(2), -1)
+	}()
+}
+
+// Test the Compare() method.
+func TestCompare(t *testing.T) {
+	type Test struct { id string; a string; b string; result int }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", a: "101", b: "1011", result: -1 },
+		Test{ id: "0001", a: "1011", b: "1011", result: 0 },
+		Test{ id: "0002", a: "1011", b: "1101", result: -1 },
+		Test{ id: "0003", a: "1", b: "0111111", result: 1 },
+		Test{ id: "0004", a: "", b: "0", result: -1 },
+		Test{ id: "0005", a: "", b: "", result: 0 },
+		Test{ id: "0006", a: "000000001", b: "00000000", result: 1 },
+		Test{ id: "0007", a: "0000000010", b: "0000000001", result: 1 },
+		// Index order, not value order: "01" is 2 as an integer and
+		// "10" is 1, but "01" is the lesser.
+		Test{ id: "0008", a: "01", b: "10", result: -1 },
+	}
+
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			if result := a.Compare(b); result != test.result {
+				t.Fatalf("got %q.Compare(%q) = %d, want %d", test.a, test.b, result, test.result)
+			}
+			if result := b.Compare(a); result != -test.result {
+				t.Fatalf("got %q.Compare(%q) = %d, want %d", test.b, test.a, result, -test.result)
+			}
+		})
+	}
+}
+
+// Test the HammingDistance() function.
+func TestHammingDistance(t *testing.T) {
+	// Test against a comparison bit by bit.
+	r := rand.New(rand.NewSource(1767))
+	for i := 0; i < 300; i++ {
+		size := r.Intn(200)
+		bytes1, bytes2 := make([]byte, (size + 7) / 8), make([]byte, (size + 7) / 8)
+		r.Read(bytes1)
+		r.Read(bytes2)
+		a, b := BytesToBitBlock(bytes1, size), BytesToBitBlock(bytes2, size)
+		distance := 0
+		for pos := 0; pos < size; pos++ {
+			if a.Get(pos) != b.Get(pos) {
+				distance++
+			}
+		}
+		if d := HammingDistance(a, b); d != distance {
+			t.Fatalf("got HammingDistance(a, b) = %d for BitBlocks of size %d, want %d", d, size, distance)
+		}
+		if d := HammingDistance(a, a); d != 0 {
+			t.Fatalf("got HammingDistance(a, a) = %d for a BitBlock of size %d, want 0", d, size)
+		}
+	}
+
+	// Test that HammingDistance() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to HammingDistance(a, b) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		HammingDistance(NewZeroBitBlock(8), NewZeroBitBlock(9))
+	}()
+}
+
+// Test the Reverse() method.
+func TestReverse(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255, 1, 2, 3, 4, 5, 6, 7}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		s := bitBlock.ToBinaryString()
+		reversed := make([]byte, size)
+		for i := 0; i < size; i++ {
+			reversed[i] = s[size - 1 - i]
+		}
+		reversedBitBlock := bitBlock.Reverse()
+		if ok := checkBitBlockBinaryString(t, reversedBitBlock, string(reversed)); !ok {
+			t.Fatalf("wrong answer for Reverse() on a BitBlock of size %d", size)
+		}
+		if !reversedBitBlock.Reverse().Equal(bitBlock) {
+			t.Fatalf("the BitBlock of size %d is different after calling Reverse() twice", size)
+		}
+	}
+}
+
+// Test the SetBitPositionsOfValue() method.
+func TestSetBitPositionsOfValue(t *testing.T) {
+	// Test that the positions are the exponents of the powers of two
+	// of the integer.
+	for _, x := range []uint64{0, 1, 2, 5, 12, 255, 256, 1000003, 0x8000000000000001} {
+		positions := Uint64ToBitBlock(x).SetBitPositionsOfValue()
+		var x2 uint64 = 0
+		for _, pos := range positions {
+			x2 += 1 << pos
+		}
+		if x2 != x {
+			t.Fatalf("got SetBitPositionsOfValue() = %v for %d, whose powers of two add up to %d", positions, x, x2)
+		}
+	}
+
+	// Test that the positions match SetBitIndicesU32().
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		positions, indices := bitBlock.SetBitPositionsOfValue(), bitBlock.SetBitIndicesU32()
+		if len(positions) != len(indices) {
+			t.Fatalf("got %d positions from SetBitPositionsOfValue() on a BitBlock of size %d, want %d", len(positions), size, len(indices))
+		}
+		for i := range positions {
+			if positions[i] != int(indices[i]) {
+				t.Fatalf("got SetBitPositionsOfValue()[%d] = %d on a BitBlock of size %d, want %d", i, positions[i], size, indices[i])
+			}
+		}
+	}
+}
+
+// Test the SetAll() and ClearAll() methods.
+func TestSetAllAndClearAll(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255, 1, 2, 3, 4, 5}
+	for _, size := range []int{0, 1, 7, 8, 9, 63, 64, 65, 135} {
+		bitBlock := BytesToBitBlock(bytes, size)
+		bitBlock.SetAll()
+		if count := bitBlock.PopCount(); count != size {
+			t.Fatalf("got PopCount() = %d after SetAll() on a BitBlock of size %d, want %d", count, size, size)
+		}
+		if ok := checkPaddingBits(t, bitBlock); !ok {
+			t.Fatalf("the call to SetAll() on a BitBlock of size %d set some padding bits to true", size)
+		}
+		bitBlock.ClearAll()
+		if !bitBlock.Equal(NewZeroBitBlock(size)) {
+			t.Fatalf("the call to ClearAll() on a BitBlock of size %d did not set all the bits to 0", size)
+		}
+	}
+}
+
+// Test the SetRange() method.
+func TestSetRange(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * 5; size++ {
+		for l := 0; l <= size; l++ {
+			for r := l; r <= size; r++ {
+				for _, value := range []bool{false, true} {
+					// Compare against repeated calls to Set().
+					bitBlock, bitBlock2 := BytesToBitBlock(bytes, size), BytesToBitBlock(bytes, size)
+					bitBlock.SetRange(l, r, value)
+					for pos := l; pos < r; pos++ {
+						bitBlock2.Set(pos, value)
+					}
+					if ok := checkBitBlockBinaryString(t, bitBlock, bitBlock2.ToBinaryString()); !ok {
+						t.Fatalf("wrong answer for SetRange(%d, %d, %t) on a BitBlock of size %d", l, r, value, size)
+					}
+				}
+			}
+		}
+	}
+
+	// Test that SetRange() panics for invalid ranges.
+	bitBlock := NewZeroBitBlock(20)
+	type Range struct { l int; r int }
+	for _, rg := range []Range{ {-1, 5}, {5, 21}, {10, 9}, {21, 25} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to SetRange(%d, %d, true) on a BitBlock of size %d did not panic", rg.l, rg.r, bitBlock.Size())
+				}
+			}()
+			bitBlock.SetRange(rg.l, rg.r, true)
+		}()
+	}
+}
+
+// Test the BiasedRandomBitBlock() function.
+func TestBiasedRandomBitBlock(t *testing.T) {
+	r := rand.New(rand.NewSource(1771))
+
+	// Test that the density of a large BitBlock is close to p.
+	size := 100000
+	for _, p := range []float64{0.3, 0.05, 0.5, 0.9} {
+		bitBlock := BiasedRandomBitBlock(size, p, r)
+		if ok := checkPaddingBits(t, bitBlock); !ok {
+			t.Fatalf("the call to BiasedRandomBitBlock(%d, %g, r) returned a BitBlock with some padding bits set to true", size, p)
+		}
+		if density := float64(bitBlock.PopCount()) / float64(size); math.Abs(density - p) > 0.01 {
+			t.Fatalf("got a density of %g for BiasedRandomBitBlock(%d, %g, r), want a density close to %g", density, size, p, p)
+		}
+	}
+
+	// Test the extreme probabilities.
+	for _, size := range []int{0, 7, 135} {
+		if bitBlock := BiasedRandomBitBlock(size, 0, r); bitBlock.PopCount() != 0 {
+			t.Fatalf("got a BitBlock with bits set to 1 for BiasedRandomBitBlock(%d, 0, r)", size)
+		}
+		if bitBlock := BiasedRandomBitBlock(size, 1, r); bitBlock.PopCount() != size {
+			t.Fatalf("got a BitBlock with bits set to 0 for BiasedRandomBitBlock(%d, 1, r)", size)
+		}
+	}
+
+	// Test that BiasedRandomBitBlock() panics for invalid arguments.
+	type Test struct { size int; p float64 }
+	for _, test := range []Test{ {-1, 0.5}, {10, -0.1}, {10, 1.1}, {10, math.NaN()} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to BiasedRandomBitBlock(%d, %g, r) did not panic", test.size, test.p)
+				}
+			}()
+			BiasedRandomBitBlock(test.size, test.p, r)
+		}()
+	}
+}
+
+// Test the Implies() function.
+func TestImplies(t *testing.T) {
+	bytes1 := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	for size := 0; size <= 8 * len(bytes1); size++ {
+		a, b := BytesToBitBlock(bytes1, size), BytesToBitBlock(bytes2, size)
+
+		// The implication is the OR of the complement of a and b.
+		correct := a.Complement()
+		correct.OrWith(b)
+		if ok := checkBitBlockBinaryString(t, Implies(a, b), correct.ToBinaryString()); !ok {
+			t.Fatalf("wrong answer for Implies(a, b) on BitBlocks of size %d", size)
+		}
+
+		// A BitBlock with all bits set to 0 implies any BitBlock.
+		if !Implies(NewZeroBitBlock(size), b).AndReduce() {
+			t.Fatalf("got some bits set to 0 in Implies(zero, b) on BitBlocks of size %d, want all bits set to 1", size)
+		}
+	}
+
+	// Test that Implies() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to Implies(a, b) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		Implies(NewZeroBitBlock(8), NewZeroBitBlock(9))
+	}()
+}
+
+// Test the Entails() function.
+func TestEntails(t *testing.T) {
+	type Test struct { id string; a string; b string; entails bool }
+
+	// Test cases.
+	tests := []Test{
+		Test{ id: "0000", a: "0100100001", b: "1101101011", entails: true },
+		Test{ id: "0001", a: "1101101011", b: "1101101011", entails: true },
+		Test{ id: "0002", a: "0000000000", b: "0000000000", entails: true },
+		Test{ id: "0003", a: "0100100001", b: "1101101010", entails: false },
+		Test{ id: "0004", a: "1111111111", b: "1111111110", entails: false },
+		Test{ id: "0005", a: "", b: "", entails: true },
+	}
+
+	for _, test := range tests {
+		a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+		t.Run(test.id, func(t *testing.T) {
+			if entails := Entails(a, b); entails != test.entails {
+				t.Fatalf("got Entails(%q, %q) = %t, want %t", test.a, test.b, entails, test.entails)
+			}
+			if entails := Implies(a, b).AndReduce(); entails != test.entails {
+				t.Fatalf("got Implies(%q, %q).AndReduce() = %t, want %t", test.a, test.b, entails, test.entails)
+			}
+		})
+	}
+
+	// Test that Entails() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to Entails(a, b) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		Entails(NewZeroBitBlock(8), NewZeroBitBlock(9))
+	}()
+}
+
+// Test the XorByte() method.
+func TestXorByte(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		if ok := checkBitBlockBinaryString(t, bitBlock.XorByte(0xFF), bitBlock.Complement().ToBinaryString()); !ok {
+			t.Fatalf("got XorByte(0xFF) different from Complement() on a BitBlock of size %d", size)
+		}
+		if ok := checkBitBlockBinaryString(t, bitBlock.XorByte(0x00), bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("got XorByte(0x00) different from the BitBlock of size %d", size)
+		}
+		for _, b := range []byte{0x01, 0x80, 0x5A, 0xC3} {
+			bools := make([]bool, size)
+			for pos := 0; pos < size; pos++ {
+				bools[pos] = bitBlock.Get(pos) != ((b >> (pos & 7)) & 1 == 1)
+			}
+			result := bitBlock.XorByte(b)
+			if ok := checkBitBlockValues(t, result, bools); !ok {
+				t.Fatalf("wrong answer for XorByte(%#02x) on a BitBlock of size %d", b, size)
+			}
+			if ok := checkPaddingBits(t, result); !ok {
+				t.Fatalf("the call to XorByte(%#02x) on a BitBlock of size %d returned a BitBlock with some padding bits set to true", b, size)
+			}
+		}
+	}
+}
+
+// Test the FillBools() method.
+func TestFillBools(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for _, length := range []int{0, 1, size / 2, size, size + 1, size + 10} {
+			dst := make([]bool, length)
+			for i := range dst {
+				dst[i] = true
+			}
+			n := bitBlock.FillBools(dst)
+			want := size
+			if length < size {
+				want = length
+			}
+			if n != want {
+				t.Fatalf("got FillBools(dst) = %d with len(dst) = %d on a BitBlock of size %d, want %d", n, length, size, want)
+			}
+			for i := 0; i < length; i++ {
+				if i < n && dst[i] != bitBlock.Get(i) {
+					t.Fatalf("got dst[%d] = %t after FillBools(dst) on a BitBlock of size %d, want %t", i, dst[i], size, bitBlock.Get(i))
+				}
+				if i >= n && !dst[i] {
+					t.Fatalf("the call to FillBools(dst) on a BitBlock of size %d modified dst[%d], beyond the bits written", size, i)
+				}
+			}
+		}
+	}
+}
+
+// Test the RandomBitBlock() function.
+func TestRandomBitBlock(t *testing.T) {
+	r1, r2 := rand.New(rand.NewSource(1776)), rand.New(rand.NewSource(1776))
+	for size := 0; size <= 200; size++ {
+		bitBlock := RandomBitBlock(size, r1)
+		if ok := checkBitBlockSize(t, bitBlock, size); !ok {
+			t.Fatalf("wrong size for RandomBitBlock(%d, r)", size)
+		}
+		if ok := checkPaddingBits(t, bitBlock); !ok {
+			t.Fatalf("the call to RandomBitBlock(%d, r) returned a BitBlock with some padding bits set to true", size)
+		}
+
+		// The same BitBlock is obtained from a source with the same seed.
+		if bitBlock2 := RandomBitBlock(size, r2); !bitBlock.Equal(bitBlock2) {
+			t.Fatalf("got different BitBlocks for RandomBitBlock(%d, r) with sources with the same seed", size)
+		}
+	}
+
+	// Test that a large BitBlock has roughly half of the bits set to 1.
+	bitBlock := RandomBitBlock(100000, r1)
+	if density := float64(bitBlock.PopCount()) / 100000; math.Abs(density - 0.5) > 0.01 {
+		t.Fatalf("got a density of %g for RandomBitBlock(100000, r), want a density close to 0.5", density)
+	}
+
+	// Test that RandomBitBlock() panics if size < 0.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to RandomBitBlock(-1, r) did not panic")
+			}
+		}()
+		RandomBitBlock(-1, r1)
+	}()
+}
+
+// Test the Repeat() method.
+func TestRepeat(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for _, size := range []int{0, 1, 3, 7, 8, 9, 13, 16, 29, 64, 100, 104} {
+		bitBlock := BytesToBitBlock(bytes, size)
+		copies := []*BitBlock{}
+		for n := 0; n <= 11; n++ {
+			if ok := checkBitBlockBinaryString(t, bitBlock.Repeat(n), Concatenate(copies...).ToBinaryString()); !ok {
+				t.Fatalf("wrong answer for Repeat(%d) on a BitBlock of size %d", n, size)
+			}
+			copies = append(copies, bitBlock)
+		}
+	}
+
+	// Test that Repeat() panics if n < 0.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to Repeat(-1) did not panic")
+			}
+		}()
+		BytesToBitBlock(bytes, 10).Repeat(-1)
+	}()
+}
+
+// Test the Stats() method.
+func TestStats(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	bitBlocks := []*BitBlock{}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlocks = append(bitBlocks, BytesToBitBlock(bytes, size), BytesToBitBlock(bytes2, size))
+	}
+	bitBlocks = append(bitBlocks, NewZeroBitBlock(50), BytesToBitBlock([]byte{255, 255, 255}, 20))
+
+	for _, bitBlock := range bitBlocks {
+		stats := bitBlock.Stats()
+
+		// The longest runs and the transitions are computed with a
+		// naive loop, since there are no standalone methods for them.
+		longestRunOnes, longestRunZeros, numTransitions := 0, 0, 0
+		for i, run := 0, 0; i < bitBlock.Size(); i++ {
+			if i > 0 && bitBlock.Get(i) != bitBlock.Get(i - 1) {
+				numTransitions++
+				run = 0
+			}
+			run++
+			if bitBlock.Get(i) && run > longestRunOnes {
+				longestRunOnes = run
+			}
+			if !bitBlock.Get(i) && run > longestRunZeros {
+				longestRunZeros = run
+			}
+		}
+
+		want := BlockStats{
+			Size: bitBlock.Size(),
+			PopCount: bitBlock.PopCount(),
+			LeadingZeros: bitBlock.CountLeadingZeros(),
+			TrailingZeros: bitBlock.CountTrailingZeros(),
+			LongestRunOnes: longestRunOnes,
+			LongestRunZeros: longestRunZeros,
+			NumTransitions: numTransitions,
+		}
+		if stats != want {
+			t.Fatalf("got Stats() = %+v for %q, want %+v", stats, bitBlock.ToBinaryString(), want)
+		}
+	}
+}
+
+// Test the Insert() method.
+func TestInsert(t *testing.T) {
+	type Test struct { id string; block string; pos int; other string; result string }
+
+	tests := []Test{
+		Test{ id: "0000", block: "", pos: 0, other: "", result: "" },
+		Test{ id: "0001", block: "", pos: 0, other: "101", result: "101" },
+		Test{ id: "0002", block: "111", pos: 0, other: "00", result: "00111" },
+		Test{ id: "0003", block: "111", pos: 3, other: "00", result: "11100" },
+		Test{ id: "0004", block: "1100", pos: 2, other: "0110", result: "11011000" },
+		Test{ id: "0005", block: "1010101", pos: 5, other: "", result: "1010101" },
+		Test{ id: "0006", block: "110011001", pos: 4, other: "000011111", result: "110000001111111001" },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			block, other := binaryStringToBitBlock(test.block), binaryStringToBitBlock(test.other)
+			if ok := checkBitBlockBinaryString(t, block.Insert(test.pos, other), test.result); !ok {
+				t.Fatalf("wrong answer for Insert(%d, %q) on %q, want %q", test.pos, test.other, test.block, test.result)
+			}
+		})
+	}
+
+	// Test that a known BitBlock is reconstructed by inserting its
+	// sub-blocks at various positions.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bitBlock := BytesToBitBlock(bytes, 8 * len(bytes) - 3)
+	size := bitBlock.Size()
+	for _, cut := range [][2]int{ {0, 0}, {0, size}, {3, 17}, {8, 16}, {13, 70}, {50, 51}, {size - 9, size} } {
+		l, r := cut[0], cut[1]
+		rest := Concatenate(bitBlock.GetSubBlock(0, l), bitBlock.GetSubBlock(r, size))
+		result := rest.Insert(l, bitBlock.GetSubBlock(l, r))
+		if ok := checkBitBlockBinaryString(t, result, bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock after inserting back the bits from %d to %d", l, r)
+		}
+	}
+
+	// Test that Insert() panics if pos is out of range.
+	for _, pos := range []int{-1, 11} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to Insert(%d, other) on a BitBlock of size 10 did not panic", pos)
+				}
+			}()
+			NewZeroBitBlock(10).Insert(pos, NewZeroBitBlock(3))
+		}()
+	}
+}
+
+// Test the MergeIndexLists() function.
+func TestMergeIndexLists(t *testing.T) {
+	type Test struct { id string; lists [][]int; size int; result string; skipped int }
+
+	tests := []Test{
+		Test{ id: "0000", lists: nil, size: 0, result: "", skipped: 0 },
+		Test{ id: "0001", lists: [][]int{}, size: 5, result: "00000", skipped: 0 },
+		Test{ id: "0002", lists: [][]int{ {0, 2, 4} }, size: 5, result: "10101", skipped: 0 },
+		Test{ id: "0003", lists: [][]int{ {0, 2, 4}, {1, 2, 3} }, size: 5, result: "11111", skipped: 0 },
+		Test{ id: "0004", lists: [][]int{ {1, 3, 5, 7}, {3, 5, 9}, {}, {0, 1, 1} }, size: 10, result: "1101010101", skipped: 0 },
+		Test{ id: "0005", lists: [][]int{ {-3, -1, 0, 4, 9, 10, 12}, {2, 4, 20} }, size: 10, result: "1010100001", skipped: 5 },
+		Test{ id: "0006", lists: [][]int{ {0, 1}, {2} }, size: 0, result: "", skipped: 3 },
+		Test{ id: "0007", lists: [][]int{ {8, 15, 16}, {15, 17} }, size: 17, result: "00000000100000011", skipped: 1 },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock, skipped := MergeIndexLists(test.lists, test.size)
+			if ok := checkBitBlockBinaryString(t, bitBlock, test.result); !ok {
+				t.Fatalf("wrong BitBlock for MergeIndexLists(%v, %d), want %q", test.lists, test.size, test.result)
+			}
+			if skipped != test.skipped {
+				t.Fatalf("got %d skipped indices for MergeIndexLists(%v, %d), want %d", skipped, test.lists, test.size, test.skipped)
+			}
+		})
+	}
+}
+
+// Test the DeleteBits() method.
+func TestDeleteBits(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for _, size := range []int{0, 1, 7, 8, 9, 16, 23, 50, 104} {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for l := 0; l <= size; l++ {
+			for r := l; r <= size; r++ {
+				correct := Concatenate(bitBlock.GetSubBlock(0, l), bitBlock.GetSubBlock(r, size))
+				if ok := checkBitBlockBinaryString(t, bitBlock.DeleteBits(l, r), correct.ToBinaryString()); !ok {
+					t.Fatalf("wrong answer for DeleteBits(%d, %d) on a BitBlock of size %d", l, r, size)
+				}
+			}
+		}
+	}
+
+	// Test that DeleteBits() panics on invalid ranges.
+	for _, lr := range [][2]int{ {-1, 3}, {4, 3}, {2, 11}, {11, 11} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to DeleteBits(%d, %d) on a BitBlock of size 10 did not panic", lr[0], lr[1])
+				}
+			}()
+			NewZeroBitBlock(10).DeleteBits(lr[0], lr[1])
+		}()
+	}
+}
+
+// Test the NearestSetBit() method.
+func TestNearestSetBit(t *testing.T) {
+	type Test struct { id string; block string; pos int; nearest int }
+
+	tests := []Test{
+		Test{ id: "0000", block: "0", pos: 0, nearest: -1 },
+		Test{ id: "0001", block: "000000000000", pos: 7, nearest: -1 },
+		Test{ id: "0002", block: "1", pos: 0, nearest: 0 },
+		Test{ id: "0003", block: "0001000", pos: 3, nearest: 3 },
+		Test{ id: "0004", block: "0001000", pos: 0, nearest: 3 },
+		Test{ id: "0005", block: "0001000", pos: 6, nearest: 3 },
+		Test{ id: "0006", block: "1000000001", pos: 3, nearest: 0 },
+		Test{ id: "0007", block: "1000000001", pos: 6, nearest: 9 },
+		Test{ id: "0008", block: "0100000100", pos: 4, nearest: 1 },
+		Test{ id: "0009", block: "00000000000000000001", pos: 2, nearest: 19 },
+		Test{ id: "0010", block: "10000000000000000000", pos: 18, nearest: 0 },
+		Test{ id: "0011", block: "00000001000000001000", pos: 11, nearest: 7 },
+		Test{ id: "0012", block: "00000001000000001000", pos: 12, nearest: 16 },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock := binaryStringToBitBlock(test.block)
+			if nearest := bitBlock.NearestSetBit(test.pos); nearest != test.nearest {
+				t.Fatalf("got NearestSetBit(%d) = %d on %q, want %d", test.pos, nearest, test.block, test.nearest)
+			}
+		})
+	}
+
+	// Test that NearestSetBit() panics if pos is out of range.
+	for _, pos := range []int{-1, 10} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to NearestSetBit(%d) on a BitBlock of size 10 did not panic", pos)
+				}
+			}()
+			NewZeroBitBlock(10).NearestSetBit(pos)
+		}()
+	}
+}
+
+// Test the AppendBit() and AppendBitBlock() methods.
+func TestAppendBit(t *testing.T) {
+	binaryString := "1101000111010110000000011111111101010010110"
+
+	// Test appending the bits one by one, starting from both an empty
+	// BitBlock and the zero value of BitBlock.
+	for _, bitBlock := range []*BitBlock{ NewZeroBitBlock(0), &BitBlock{} } {
+		for i := 0; i < len(binaryString); i++ {
+			bitBlock.AppendBit(binaryString[i] == '1')
+			if ok := checkBitBlockBinaryString(t, bitBlock, binaryString[:i+1]); !ok {
+				t.Fatalf("wrong BitBlock after appending the bit %d with AppendBit()", i)
+			}
+		}
+	}
+
+	// Test appending BitBlocks of several sizes, including empty ones.
+	for _, step := range []int{0, 1, 3, 8, 11, 20} {
+		bitBlock := binaryStringToBitBlock("101")
+		want := "101"
+		for l := 0; l < len(binaryString); l += step {
+			r := l + step
+			if r > len(binaryString) {
+				r = len(binaryString)
+			}
+			bitBlock.AppendBitBlock(binaryStringToBitBlock(binaryString[l:r]))
+			want += binaryString[l:r]
+			if ok := checkBitBlockBinaryString(t, bitBlock, want); !ok {
+				t.Fatalf("wrong BitBlock after appending %q with AppendBitBlock()", binaryString[l:r])
+			}
+			if step == 0 {
+				break
+			}
+		}
+	}
+}
+
+// Test the ToProgressSegments() method.
+func TestToProgressSegments(t *testing.T) {
+	type Test struct { id string; block string; width int; segments string }
+
+	tests := []Test{
+		Test{ id: "0000", block: "", width: 3, segments: "000" },
+		Test{ id: "0001", block: "1", width: 1, segments: "1" },
+		Test{ id: "0002", block: "1", width: 4, segments: "1111" },
+		Test{ id: "0003", block: "10", width: 4, segments: "1100" },
+		Test{ id: "0004", block: "110100", width: 3, segments: "100" },
+		Test{ id: "0005", block: "111000111000", width: 4, segments: "1010" },
+		Test{ id: "0006", block: "1110001110", width: 2, segments: "11" },
+		Test{ id: "0007", block: "0110", width: 1, segments: "0" },
+		Test{ id: "0008", block: "0111", width: 1, segments: "1" },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			segments := binaryStringToBitBlock(test.block).ToProgressSegments(test.width)
+			got := make([]byte, len(segments))
+			for i, segment := range segments {
+				got[i] = '0'
+				if segment {
+					got[i] = '1'
+				}
+			}
+			if string(got) != test.segments {
+				t.Fatalf("got ToProgressSegments(%d) = %q on %q, want %q", test.width, string(got), test.block, test.segments)
+			}
+		})
+	}
+
+	// Test that a half-set BitBlock gives a roughly half-filled bar
+	// with exactly width cells.
+	bitBlock := NewZeroBitBlock(1000)
+	bitBlock.SetRange(0, 500, true)
+	for _, width := range []int{1, 2, 7, 10, 33, 80, 1000, 1500} {
+		segments := bitBlock.ToProgressSegments(width)
+		if len(segments) != width {
+			t.Fatalf("got %d cells for ToProgressSegments(%d), want %d", len(segments), width, width)
+		}
+		filled := 0
+		for _, segment := range segments {
+			if segment {
+				filled++
+			}
+		}
+		if filled < width / 2 - 1 || filled > width / 2 + 1 {
+			t.Fatalf("got %d filled cells for ToProgressSegments(%d) on a half-set BitBlock, want about %d", filled, width, width / 2)
+		}
+	}
+
+	// Test that ToProgressSegments() panics if width < 1.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to ToProgressSegments(0) did not panic")
+			}
+		}()
+		bitBlock.ToProgressSegments(0)
+	}()
+}
+
+// Test the Prepend() method.
+func TestPrepend(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	sizes := []int{0, 1, 3, 7, 8, 9, 13, 16, 21, 64, 77, 104}
+	for _, size1 := range sizes {
+		for _, size2 := range sizes {
+			block, other := BytesToBitBlock(bytes, size1), BytesToBitBlock(bytes2, size2)
+			correct := other.ToBinaryString() + block.ToBinaryString()
+			if ok := checkBitBlockBinaryString(t, block.Prepend(other), correct); !ok {
+				t.Fatalf("wrong answer for Prepend(other) with other of size %d on a BitBlock of size %d", size2, size1)
+			}
+		}
+	}
+}
+
+// Test the AreDisjoint() function.
+func TestAreDisjoint(t *testing.T) {
+	type Test struct { id string; a string; b string; disjoint bool }
+
+	tests := []Test{
+		Test{ id: "0000", a: "", b: "", disjoint: true },
+		Test{ id: "0001", a: "0000000000", b: "1011011101", disjoint: true },
+		Test{ id: "0002", a: "1010101010", b: "0101010101", disjoint: true },
+		Test{ id: "0003", a: "1010101010", b: "0101010111", disjoint: false },
+		Test{ id: "0004", a: "1", b: "1", disjoint: false },
+		Test{ id: "0005", a: "00000000000000000001", b: "11111111111111111111", disjoint: false },
+		Test{ id: "0006", a: "11110000111100000000", b: "00001111000011111111", disjoint: true },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			a, b := binaryStringToBitBlock(test.a), binaryStringToBitBlock(test.b)
+			if disjoint := AreDisjoint(a, b); disjoint != test.disjoint {
+				t.Fatalf("got AreDisjoint(%q, %q) = %t, want %t", test.a, test.b, disjoint, test.disjoint)
+			}
+			if disjoint := AreDisjoint(b, a); disjoint != test.disjoint {
+				t.Fatalf("got AreDisjoint(%q, %q) = %t, want %t", test.b, test.a, disjoint, test.disjoint)
+			}
+		})
+	}
+
+	// Test that AreDisjoint() panics if the sizes are different.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to AreDisjoint(a, b) on BitBlocks of sizes 8 and 9 did not panic")
+			}
+		}()
+		AreDisjoint(NewZeroBitBlock(8), NewZeroBitBlock(9))
+	}()
+}
+
+// Test the TryNewZeroBitBlock() and TryBytesToBitBlock() functions.
+func TestTryConstructors(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test that valid sizes give the same BitBlocks as the panicking
+	// functions and a nil error.
+	for size := 0; size <= 8 * len(bytes) + 10; size++ {
+		bitBlock, err := TryNewZeroBitBlock(size)
+		if err != nil {
+			t.Fatalf("got error %q from TryNewZeroBitBlock(%d), want nil", err, size)
+		}
+		if ok := checkBitBlockBinaryString(t, bitBlock, NewZeroBitBlock(size).ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock for TryNewZeroBitBlock(%d)", size)
+		}
+		bitBlock, err = TryBytesToBitBlock(bytes, size)
+		if err != nil {
+			t.Fatalf("got error %q from TryBytesToBitBlock(src, %d), want nil", err, size)
+		}
+		if ok := checkBitBlockBinaryString(t, bitBlock, BytesToBitBlock(bytes, size).ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock for TryBytesToBitBlock(src, %d)", size)
+		}
+	}
+
+	// Test that negative sizes return an error and a nil BitBlock.
+	for _, size := range []int{-1, -8, -100} {
+		if bitBlock, err := TryNewZeroBitBlock(size); err == nil || bitBlock != nil {
+			t.Fatalf("got (%v, %v) from TryNewZeroBitBlock(%d), want a nil BitBlock and an error", bitBlock, err, size)
+		}
+		if bitBlock, err := TryBytesToBitBlock(bytes, size); err == nil || bitBlock != nil {
+			t.Fatalf("got (%v, %v) from TryBytesToBitBlock(src, %d), want a nil BitBlock and an error", bitBlock, err, size)
+		}
+	}
+}
+
+// Test the GetOK() and SetOK() methods.
+func TestGetOKSetOK(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for _, size := range []int{0, 1, 7, 8, 9, 50, 104} {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for pos := -10; pos < size + 10; pos++ {
+			inRange := 0 <= pos && pos < size
+
+			value, ok := bitBlock.GetOK(pos)
+			if ok != inRange {
+				t.Fatalf("got ok = %t for GetOK(%d) on a BitBlock of size %d, want %t", ok, pos, size, inRange)
+			}
+			if inRange && value != bitBlock.Get(pos) {
+				t.Fatalf("got GetOK(%d) = %t on a BitBlock of size %d, want %t", pos, value, size, bitBlock.Get(pos))
+			}
+			if !inRange && value {
+				t.Fatalf("got GetOK(%d) = true on a BitBlock of size %d, want false for an out-of-range position", pos, size)
+			}
+
+			// Flip the bit with SetOK() and check that only that bit changed.
+			want := bitBlock.ToBinaryString()
+			if inRange {
+				flipped := []byte(want)
+				flipped[pos] ^= 1
+				want = string(flipped)
+			}
+			if ok := bitBlock.SetOK(pos, !value); ok != inRange {
+				t.Fatalf("got SetOK(%d, %t) = %t on a BitBlock of size %d, want %t", pos, !value, ok, size, inRange)
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock, want); !ok {
+				t.Fatalf("wrong BitBlock after SetOK(%d, %t) on a BitBlock of size %d", pos, !value, size)
+			}
+		}
+	}
+}
+
+// Test the Resize() method.
+func TestResize(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for _, size := range []int{0, 1, 7, 8, 9, 15, 16, 17, 50, 104} {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for newSize := 0; newSize <= size + 20; newSize++ {
+			// Growing appends bits set to 0 and shrinking discards the
+			// highest bits.
+			var correct string
+			if newSize >= size {
+				correct = bitBlock.ToBinaryString() + NewZeroBitBlock(newSize - size).ToBinaryString()
+			} else {
+				correct = bitBlock.RemoveLastBits(size - newSize).ToBinaryString()
+			}
+			if ok := checkBitBlockBinaryString(t, bitBlock.Resize(newSize), correct); !ok {
+				t.Fatalf("wrong answer for Resize(%d) on a BitBlock of size %d", newSize, size)
+			}
+		}
+	}
+
+	// Test that shrinking and growing back clears the discarded bits.
+	bitBlock := BytesToBitBlock([]byte{255, 255}, 16).Resize(5).Resize(16)
+	if ok := checkBitBlockBinaryString(t, bitBlock, "1111100000000000"); !ok {
+		t.Fatalf("wrong answer for Resize(5).Resize(16) on a BitBlock with all its bits set to 1")
+	}
+
+	// Test that Resize() panics if newSize < 0.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to Resize(-1) did not panic")
+			}
+		}()
+		BytesToBitBlock(bytes, 10).Resize(-1)
+	}()
+}
+
+// Test the ForEach() method.
+func TestForEach(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+
+	// Test that all the bits are visited in order when fn always
+	// returns true.
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		count := 0
+		bitBlock.ForEach(func(index int, value bool) bool {
+			if index != count || value != bitBlock.Get(index) {
+				t.Fatalf("got fn(%d, %t) after %d calls on a BitBlock of size %d, want fn(%d, %t)", index, value, count, size, count, bitBlock.Get(count))
+			}
+			count++
+			return true
+		})
+		if count != size {
+			t.Fatalf("got %d calls to fn on a BitBlock of size %d, want %d", count, size, size)
+		}
+	}
+
+	// Test that the iteration stops after the first set bit.
+	bitBlock := binaryStringToBitBlock("0000010110")
+	calls := []int{}
+	bitBlock.ForEach(func(index int, value bool) bool {
+		calls = append(calls, index)
+		return !value
+	})
+	if len(calls) != 6 || calls[5] != 5 {
+		t.Fatalf("got calls to fn at positions %v, want the positions 0 to 5", calls)
+	}
+}
+
+// Test the Splice() method.
+func TestSplice(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	for _, size := range []int{0, 1, 8, 13, 40} {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for _, replacementSize := range []int{0, 1, 5, 8, 19} {
+			replacement := BytesToBitBlock(bytes2, replacementSize)
+			for l := 0; l <= size; l++ {
+				for r := l; r <= size; r++ {
+					// The surrounding bits are preserved around the replacement.
+					correct := bitBlock.GetSubBlock(0, l).ToBinaryString() + replacement.ToBinaryString() + bitBlock.GetSubBlock(r, size).ToBinaryString()
+					if ok := checkBitBlockBinaryString(t, bitBlock.Splice(l, r, replacement), correct); !ok {
+						t.Fatalf("wrong answer for Splice(%d, %d, replacement) with a replacement of size %d on a BitBlock of size %d", l, r, replacementSize, size)
+					}
+				}
+			}
+		}
+	}
+
+	// Test that Splice() generalizes Insert() and DeleteBits().
+	bitBlock := BytesToBitBlock(bytes, 50)
+	other := BytesToBitBlock(bytes2, 11)
+	if !bitBlock.Splice(17, 17, other).Equal(bitBlock.Insert(17, other)) {
+		t.Fatalf("got Splice(17, 17, other) different from Insert(17, other)")
+	}
+	if !bitBlock.Splice(9, 30, NewZeroBitBlock(0)).Equal(bitBlock.DeleteBits(9, 30)) {
+		t.Fatalf("got Splice(9, 30, empty) different from DeleteBits(9, 30)")
+	}
+
+	// Test that Splice() panics on invalid ranges.
+	for _, lr := range [][2]int{ {-1, 3}, {4, 3}, {2, 11}, {11, 11} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to Splice(%d, %d, replacement) on a BitBlock of size 10 did not panic", lr[0], lr[1])
+				}
+			}()
+			NewZeroBitBlock(10).Splice(lr[0], lr[1], other)
+		}()
+	}
+}
+
+// Test the CopyBits() function.
+func TestCopyBits(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	src := BytesToBitBlock(bytes, 45)
+	for _, dstSize := range []int{0, 1, 9, 30} {
+		dstString := BytesToBitBlock(bytes2, dstSize).ToBinaryString()
+		for n := 0; n <= dstSize; n++ {
+			for srcPos := 0; srcPos + n <= src.Size(); srcPos += 3 {
+				for dstPos := 0; dstPos + n <= dstSize; dstPos++ {
+					dst := BytesToBitBlock(bytes2, dstSize)
+					CopyBits(dst, dstPos, src, srcPos, n)
+					// The bits of dst outside the copied range are untouched.
+					correct := dstString[:dstPos] + src.ToBinaryString()[srcPos:srcPos + n] + dstString[dstPos + n:]
+					if ok := checkBitBlockBinaryString(t, dst, correct); !ok {
+						t.Fatalf("wrong answer for CopyBits(dst, %d, src, %d, %d) with dst of size %d", dstPos, srcPos, n, dstSize)
+					}
+				}
+			}
+		}
+	}
+
+	// Test that CopyBits() panics on invalid ranges.
+	type Test struct { id string; dstPos int; srcPos int; n int }
+	tests := []Test{
+		Test{ id: "0000", dstPos: 0, srcPos: 0, n: -1 },
+		Test{ id: "0001", dstPos: -1, srcPos: 0, n: 3 },
+		Test{ id: "0002", dstPos: 0, srcPos: -1, n: 3 },
+		Test{ id: "0003", dstPos: 8, srcPos: 0, n: 3 },
+		Test{ id: "0004", dstPos: 0, srcPos: 18, n: 3 },
+		Test{ id: "0005", dstPos: 0, srcPos: 0, n: 11 },
+		Test{ id: "0006", dstPos: 11, srcPos: 0, n: 0 },
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to CopyBits(dst, %d, src, %d, %d) with sizes 10 and 20 did not panic", test.dstPos, test.srcPos, test.n)
+				}
+			}()
+			CopyBits(NewZeroBitBlock(10), test.dstPos, NewZeroBitBlock(20), test.srcPos, test.n)
+		})
+	}
+}
+
+// Test the GetByte() and SetByte() methods.
+func TestGetByteSetByte(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for _, size := range []int{8, 15, 16, 17, 60, 104} {
+		bitBlock := BytesToBitBlock(bytes, size)
+		for byteIndex := 0; 8 * byteIndex + 8 <= size; byteIndex++ {
+			if b := bitBlock.GetByte(byteIndex); b != bytes[byteIndex] {
+				t.Fatalf("got GetByte(%d) = %d on a BitBlock of size %d, want %d", byteIndex, b, size, bytes[byteIndex])
+			}
+
+			// Set a byte and read back the individual bits.
+			value := bytes[byteIndex] ^ 0xA5
+			bitBlock.SetByte(byteIndex, value)
+			for i := 0; i < 8; i++ {
+				if want := (value >> i) & 1 == 1; bitBlock.Get(8 * byteIndex + i) != want {
+					t.Fatalf("got Get(%d) = %t after SetByte(%d, %d), want %t", 8 * byteIndex + i, !want, byteIndex, value, want)
+				}
+			}
+			if b := bitBlock.GetByte(byteIndex); b != value {
+				t.Fatalf("got GetByte(%d) = %d after SetByte(%d, %d), want %d", byteIndex, b, byteIndex, value, value)
+			}
+			if ok := checkPaddingBits(t, bitBlock); !ok {
+				t.Fatalf("the call to SetByte(%d, %d) on a BitBlock of size %d set some padding bits to 1", byteIndex, value, size)
+			}
+		}
+	}
+
+	// Test that GetByte() and SetByte() panic if the byte exceeds the
+	// size, even partially.
+	for _, byteIndex := range []int{-1, 2, 3} {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to GetByte(%d) on a BitBlock of size 20 did not panic", byteIndex)
+				}
+			}()
+			NewZeroBitBlock(20).GetByte(byteIndex)
+		}()
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to SetByte(%d, 255) on a BitBlock of size 20 did not panic", byteIndex)
+				}
+			}()
+			NewZeroBitBlock(20).SetByte(byteIndex, 255)
+		}()
+	}
+}
+
+// Test the GetBits() and PutBits() methods.
+func TestGetBitsPutBits(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bitBlock := BytesToBitBlock(bytes, 100)
+	values := []uint64{0, 1, 5, 0xA5A5A5A5A5A5A5A5, 0xFFFFFFFFFFFFFFFF, 1234567890123456789}
+	for n := 0; n <= 64; n++ {
+		for pos := 0; pos + n <= bitBlock.Size(); pos += 3 {
+			// GetBits() agrees with a loop of Get() calls.
+			var correct uint64 = 0
+			for i := n - 1; i >= 0; i-- {
+				correct <<= 1
+				if bitBlock.Get(pos + i) {
+					correct |= 1
+				}
+			}
+			if x := bitBlock.GetBits(pos, n); x != correct {
+				t.Fatalf("got GetBits(%d, %d) = %#x, want %#x", pos, n, x, correct)
+			}
+
+			// PutBits() writes only the n bits and GetBits() reads them back.
+			for _, value := range values {
+				result := bitBlock.Clone()
+				result.PutBits(pos, n, value)
+				want := bitBlock.ToBinaryString()[:pos] + UintToBitBlockN(value, n).ToBinaryString() + bitBlock.ToBinaryString()[pos + n:]
+				if ok := checkBitBlockBinaryString(t, result, want); !ok {
+					t.Fatalf("wrong BitBlock after PutBits(%d, %d, %#x)", pos, n, value)
+				}
+				if x, want := result.GetBits(pos, n), value & FirstBitsSet1Uint64(n); x != want {
+					t.Fatalf("got GetBits(%d, %d) = %#x after PutBits(%d, %d, %#x), want %#x", pos, n, x, pos, n, value, want)
+				}
+			}
+		}
+	}
+
+	// Test that GetBits() and PutBits() panic on invalid ranges.
+	for _, pn := range [][2]int{ {0, -1}, {0, 65}, {-1, 3}, {95, 6}, {101, 0} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to GetBits(%d, %d) on a BitBlock of size 100 did not panic", pn[0], pn[1])
+				}
+			}()
+			bitBlock.GetBits(pn[0], pn[1])
+		}()
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to PutBits(%d, %d, 0) on a BitBlock of size 100 did not panic", pn[0], pn[1])
+				}
+			}()
+			bitBlock.PutBits(pn[0], pn[1], 0)
+		}()
+	}
+}
+
+// Benchmark GetSubBlock() extracting almost all the bits of a
+// BitBlock of 1 MB, from a position that is a multiple of 8.
+func BenchmarkGetSubBlockAligned(b *testing.B) {
+	size := 8 << 20
+	bitBlock := NewZeroBitBlock(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitBlock.GetSubBlock(8, size - 3)
+	}
+}
+
+// Benchmark GetSubBlock() extracting almost all the bits of a
+// BitBlock of 1 MB, from a position that is not a multiple of 8.
+func BenchmarkGetSubBlockUnaligned(b *testing.B) {
+	size := 8 << 20
+	bitBlock := NewZeroBitBlock(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitBlock.GetSubBlock(5, size - 3)
+	}
+}
+
+// Benchmark Concatenate() with 64 BitBlocks of about 16 KB each,
+// most of them starting at a position that is not a multiple of 8.
+func BenchmarkConcatenate(b *testing.B) {
+	bitBlocks := make([]*BitBlock, 64)
+	for i := range bitBlocks {
+		bitBlocks[i] = NewZeroBitBlock((8 << 14) + i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Concatenate(bitBlocks...)
+	}
+}
+
+// toBinaryStringBitByBit returns bitBlock as a binary string,
+// reading its bits one by one with Get(), as ToBinaryString() used
+// to do.
+func toBinaryStringBitByBit(bitBlock *BitBlock) string {
+	binChars := make([]byte, bitBlock.Size())
+	for i := 0; i < bitBlock.Size(); i++ {
+		if bitBlock.Get(i) {
+			binChars[i] = '1'
+		} else {
+			binChars[i] = '0'
+		}
+	}
+	return string(binChars)
+}
+
+// Test that ToBinaryString() gives the same result as reading the
+// bits one by one.
+func TestToBinaryString(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		if s, want := bitBlock.ToBinaryString(), toBinaryStringBitByBit(bitBlock); s != want {
+			t.Fatalf("got ToBinaryString() = %q on a BitBlock of size %d, want %q", s, size, want)
+		}
+	}
+	if s := binaryStringToBitBlock("1101000").ToBinaryString(); s != "1101000" {
+		t.Fatalf("got ToBinaryString() = %q, want %q", s, "1101000")
+	}
+}
+
+// Benchmark ToBinaryString() on a BitBlock of 1 megabit.
+func BenchmarkToBinaryString(b *testing.B) {
+	bitBlock := NewZeroBitBlock(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bitBlock.ToBinaryString()
+	}
+}
+
+// Benchmark reading the bits one by one to build the binary string
+// of a BitBlock of 1 megabit, for comparison with ToBinaryString().
+func BenchmarkToBinaryStringBitByBit(b *testing.B) {
+	bitBlock := NewZeroBitBlock(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		toBinaryStringBitByBit(bitBlock)
+	}
+}
+
+// Test the FindLastSet() and FindLastUnset() methods.
+func TestFindLast(t *testing.T) {
+	type Test struct { id string; block string; lastSet int; lastUnset int }
+
+	tests := []Test{
+		Test{ id: "0000", block: "", lastSet: -1, lastUnset: -1 },
+		Test{ id: "0001", block: "1", lastSet: 0, lastUnset: -1 },
+		Test{ id: "0002", block: "0", lastSet: -1, lastUnset: 0 },
+		Test{ id: "0003", block: "1000000000000000000", lastSet: 0, lastUnset: 18 },
+		Test{ id: "0004", block: "0111111111111111111", lastSet: 18, lastUnset: 0 },
+		Test{ id: "0005", block: "1111111111111", lastSet: 12, lastUnset: -1 },
+		Test{ id: "0006", block: "0000000000000000", lastSet: -1, lastUnset: 15 },
+		Test{ id: "0007", block: "0010110011111111", lastSet: 15, lastUnset: 7 },
+		Test{ id: "0008", block: "110100000000", lastSet: 3, lastUnset: 11 },
+		Test{ id: "0009", block: "00000000100000001", lastSet: 16, lastUnset: 15 },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock := binaryStringToBitBlock(test.block)
+			if pos := bitBlock.FindLastSet(); pos != test.lastSet {
+				t.Fatalf("got FindLastSet() = %d on %q, want %d", pos, test.block, test.lastSet)
+			}
+			if pos := bitBlock.FindLastUnset(); pos != test.lastUnset {
+				t.Fatalf("got FindLastUnset() = %d on %q, want %d", pos, test.block, test.lastUnset)
+			}
+		})
+	}
+
+	// Test against a naive scan on many BitBlocks.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		bitBlock := BytesToBitBlock(bytes, size)
+		lastSet, lastUnset := -1, -1
+		for pos := 0; pos < size; pos++ {
+			if bitBlock.Get(pos) {
+				lastSet = pos
+			} else {
+				lastUnset = pos
+			}
+		}
+		if pos := bitBlock.FindLastSet(); pos != lastSet {
+			t.Fatalf("got FindLastSet() = %d on a BitBlock of size %d, want %d", pos, size, lastSet)
+		}
+		if pos := bitBlock.FindLastUnset(); pos != lastUnset {
+			t.Fatalf("got FindLastUnset() = %d on a BitBlock of size %d, want %d", pos, size, lastUnset)
+		}
+	}
+}
+
+// Test the Parity() method.
+func TestParity(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bytes2 := []byte{200, 15, 95, 0, 127, 34, 128, 19, 54, 255, 183, 15, 127}
+	for size := 0; size <= 8 * len(bytes); size++ {
+		for _, bitBlock := range []*BitBlock{ BytesToBitBlock(bytes, size), BytesToBitBlock(bytes2, size) } {
+			if parity, want := bitBlock.Parity(), bitBlock.PopCount() % 2 == 1; parity != want {
+				t.Fatalf("got Parity() = %t on %q, want %t", parity, bitBlock.ToBinaryString(), want)
+			}
+		}
+	}
+	if NewZeroBitBlock(0).Parity() {
+		t.Fatalf("got Parity() = true on an empty BitBlock, want false")
+	}
+}
+
+// Test the ToGray() and FromGray() methods.
+func TestGray(t *testing.T) {
+	// Test against the Gray code of integers, x XOR (x >> 1).
+	for size := 0; size <= 10; size++ {
+		for x := uint64(0); x < 1 << size; x++ {
+			bitBlock, gray := uint64ToTestBitBlock(x, size), uint64ToTestBitBlock(x ^ (x >> 1), size)
+			if ok := checkBitBlockBinaryString(t, bitBlock.ToGray(), gray.ToBinaryString()); !ok {
+				t.Fatalf("wrong answer for ToGray() on the integer %d with size %d", x, size)
+			}
+			if ok := checkBitBlockBinaryString(t, gray.FromGray(), bitBlock.ToBinaryString()); !ok {
+				t.Fatalf("wrong answer for FromGray() on the Gray code of the integer %d with size %d", x, size)
+			}
+		}
+	}
+
+	// Test that consecutive integers have Gray codes differing in
+	// exactly one bit.
+	for x := uint64(0); x < 1000; x++ {
+		a, b := uint64ToTestBitBlock(x, 12).ToGray(), uint64ToTestBitBlock(x + 1, 12).ToGray()
+		if d := HammingDistance(a, b); d != 1 {
+			t.Fatalf("got Gray codes of %d and %d differing in %d bits, want 1", x, x + 1, d)
+		}
+	}
+
+	// Test the round trip on many random BitBlocks.
+	r := rand.New(rand.NewSource(1811))
+	for i := 0; i < 500; i++ {
+		bitBlock := RandomBitBlock(r.Intn(300), r)
+		if ok := checkBitBlockBinaryString(t, bitBlock.ToGray().FromGray(), bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("wrong answer for FromGray(ToGray()) on a BitBlock of size %d", bitBlock.Size())
+		}
+		if ok := checkBitBlockBinaryString(t, bitBlock.FromGray().ToGray(), bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("wrong answer for ToGray(FromGray()) on a BitBlock of size %d", bitBlock.Size())
+		}
+	}
+}
+
+// Test the HasPrefix() and HasSuffix() methods.
+func TestHasPrefixHasSuffix(t *testing.T) {
+	type Test struct { id string; block string; other string; hasPrefix bool; hasSuffix bool }
+
+	tests := []Test{
+		Test{ id: "0000", block: "", other: "", hasPrefix: true, hasSuffix: true },
+		Test{ id: "0001", block: "1011", other: "", hasPrefix: true, hasSuffix: true },
+		Test{ id: "0002", block: "1011", other: "1011", hasPrefix: true, hasSuffix: true },
+		Test{ id: "0003", block: "1011", other: "10110", hasPrefix: false, hasSuffix: false },
+		Test{ id: "0004", block: "1011", other: "10", hasPrefix: true, hasSuffix: false },
+		Test{ id: "0005", block: "1011", other: "11", hasPrefix: false, hasSuffix: true },
+		Test{ id: "0006", block: "", other: "0", hasPrefix: false, hasSuffix: false },
+		Test{ id: "0007", block: "110100101110011", other: "110100101", hasPrefix: true, hasSuffix: false },
+		Test{ id: "0008", block: "110100101110011", other: "110100100", hasPrefix: false, hasSuffix: false },
+		Test{ id: "0009", block: "110100101110011", other: "01110011", hasPrefix: false, hasSuffix: true },
+		Test{ id: "0010", block: "11010010111001101", other: "11010010", hasPrefix: true, hasSuffix: false },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			block, other := binaryStringToBitBlock(test.block), binaryStringToBitBlock(test.other)
+			if hasPrefix := block.HasPrefix(other); hasPrefix != test.hasPrefix {
+				t.Fatalf("got HasPrefix(%q) = %t on %q, want %t", test.other, hasPrefix, test.block, test.hasPrefix)
+			}
+			if hasSuffix := block.HasSuffix(other); hasSuffix != test.hasSuffix {
+				t.Fatalf("got HasSuffix(%q) = %t on %q, want %t", test.other, hasSuffix, test.block, test.hasSuffix)
+			}
+		})
+	}
+
+	// Test that every prefix and suffix of a BitBlock is detected, and
+	// that flipping one of their bits makes them not match.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bitBlock := BytesToBitBlock(bytes, 70)
+	for n := 0; n <= bitBlock.Size(); n++ {
+		prefix, suffix := bitBlock.GetSubBlock(0, n), bitBlock.GetSubBlock(70 - n, 70)
+		if !bitBlock.HasPrefix(prefix) || !bitBlock.HasSuffix(suffix) {
+			t.Fatalf("got a prefix or suffix of %d bits not detected", n)
+		}
+		if n > 0 {
+			prefix.Set(n - 1, !prefix.Get(n - 1))
+			suffix.Set(0, !suffix.Get(0))
+			if bitBlock.HasPrefix(prefix) || bitBlock.HasSuffix(suffix) {
+				t.Fatalf("got a modified prefix or suffix of %d bits detected", n)
+			}
+		}
+	}
+}
+
+// Test the Index() method.
+func TestIndex(t *testing.T) {
+	type Test struct { id string; block string; pattern string; index int }
+
+	tests := []Test{
+		Test{ id: "0000", block: "", pattern: "", index: 0 },
+		Test{ id: "0001", block: "1011", pattern: "", index: 0 },
+		Test{ id: "0002", block: "", pattern: "1", index: -1 },
+		Test{ id: "0003", block: "1011", pattern: "10110", index: -1 },
+		Test{ id: "0004", block: "1011000", pattern: "1011", index: 0 },
+		Test{ id: "0005", block: "0001101100", pattern: "1101", index: 3 },
+		Test{ id: "0006", block: "0000000000111", pattern: "111", index: 10 },
+		Test{ id: "0007", block: "0101010101", pattern: "11", index: -1 },
+		Test{ id: "0008", block: "0110110110", pattern: "0110", index: 0 },
+		Test{ id: "0009", block: "00000000111100001111", pattern: "11110000", index: 8 },
+		Test{ id: "0010", block: "00000000111100001111", pattern: "1111", index: 8 },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			block, pattern := binaryStringToBitBlock(test.block), binaryStringToBitBlock(test.pattern)
+			if index := block.Index(pattern); index != test.index {
+				t.Fatalf("got Index(%q) = %d on %q, want %d", test.pattern, index, test.block, test.index)
+			}
+		})
+	}
+
+	// Test against a search on the binary strings with long patterns at
+	// aligned and unaligned positions.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bitBlock := BytesToBitBlock(bytes, 8 * len(bytes))
+	s := bitBlock.ToBinaryString()
+	for l := 0; l < bitBlock.Size(); l += 5 {
+		for _, n := range []int{1, 3, 8, 13, 64, 70} {
+			if l + n > bitBlock.Size() {
+				continue
+			}
+			pattern := bitBlock.GetSubBlock(l, l + n)
+			want := -1
+			for pos := 0; pos + n <= len(s); pos++ {
+				if s[pos:pos + n] == s[l:l + n] {
+					want = pos
+					break
+				}
+			}
+			if index := bitBlock.Index(pattern); index != want {
+				t.Fatalf("got Index(%q) = %d, want %d", pattern.ToBinaryString(), index, want)
+			}
+		}
+	}
+}
+
+// Test the Count() method.
+func TestCount(t *testing.T) {
+	type Test struct { id string; block string; pattern string; count int }
+
+	tests := []Test{
+		Test{ id: "0000", block: "", pattern: "", count: 1 },
+		Test{ id: "0001", block: "1011", pattern: "", count: 5 },
+		Test{ id: "0002", block: "", pattern: "1", count: 0 },
+		Test{ id: "0003", block: "1011", pattern: "10110", count: 0 },
+		Test{ id: "0004", block: "11111", pattern: "11", count: 2 },
+		Test{ id: "0005", block: "111111", pattern: "11", count: 3 },
+		Test{ id: "0006", block: "0101010", pattern: "010", count: 2 },
+		Test{ id: "0007", block: "1011", pattern: "1", count: 3 },
+		Test{ id: "0008", block: "1011", pattern: "0", count: 1 },
+		Test{ id: "0009", block: "0000000000000000", pattern: "000", count: 5 },
+		Test{ id: "0010", block: "1100110011001100110", pattern: "0110", count: 4 },
+		Test{ id: "0011", block: "1001001001", pattern: "1001", count: 2 },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			block, pattern := binaryStringToBitBlock(test.block), binaryStringToBitBlock(test.pattern)
+			if count := block.Count(pattern); count != test.count {
+				t.Fatalf("got Count(%q) = %d on %q, want %d", test.pattern, count, test.block, test.count)
+			}
+		})
+	}
+}
+
+// Test the SliceStride() method.
+func TestSliceStride(t *testing.T) {
+	type Test struct { id string; block string; l int; r int; step int; result string }
+
+	tests := []Test{
+		Test{ id: "0000", block: "", l: 0, r: 0, step: 2, result: "" },
+		Test{ id: "0001", block: "1011001", l: 0, r: 7, step: 1, result: "1011001" },
+		Test{ id: "0002", block: "1011001", l: 0, r: 7, step: 2, result: "1101" },
+		Test{ id: "0003", block: "1011001", l: 1, r: 7, step: 2, result: "010" },
+		Test{ id: "0004", block: "1011001", l: 0, r: 7, step: 3, result: "111" },
+		Test{ id: "0005", block: "1011001", l: 2, r: 6, step: 3, result: "10" },
+		Test{ id: "0006", block: "1011001", l: 3, r: 3, step: 3, result: "" },
+		Test{ id: "0007", block: "1011001", l: 3, r: 4, step: 10, result: "1" },
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			bitBlock := binaryStringToBitBlock(test.block).SliceStride(test.l, test.r, test.step)
+			if ok := checkBitBlockBinaryString(t, bitBlock, test.result); !ok {
+				t.Fatalf("wrong answer for SliceStride(%d, %d, %d) on %q, want %q", test.l, test.r, test.step, test.block, test.result)
+			}
+		})
+	}
+
+	// Test against a manual loop of Get() calls.
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bitBlock := BytesToBitBlock(bytes, 40)
+	for _, step := range []int{1, 2, 3, 7, 8, 9, 50} {
+		for l := 0; l <= bitBlock.Size(); l++ {
+			for r := l; r <= bitBlock.Size(); r++ {
+				correct := []byte{}
+				for pos := l; pos < r; pos += step {
+					if bitBlock.Get(pos) {
+						correct = append(correct, '1')
+					} else {
+						correct = append(correct, '0')
+					}
+				}
+				if ok := checkBitBlockBinaryString(t, bitBlock.SliceStride(l, r, step), string(correct)); !ok {
+					t.Fatalf("wrong answer for SliceStride(%d, %d, %d)", l, r, step)
+				}
+			}
+		}
+	}
+
+	// Test that SliceStride() panics on invalid ranges and steps.
+	for _, args := range [][3]int{ {-1, 3, 1}, {4, 3, 1}, {2, 11, 1}, {0, 5, 0}, {0, 5, -2} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to SliceStride(%d, %d, %d) on a BitBlock of size 10 did not panic", args[0], args[1], args[2])
+				}
+			}()
+			NewZeroBitBlock(10).SliceStride(args[0], args[1], args[2])
+		}()
+	}
+}
+
+// Test the Swap() method.
+func TestSwap(t *testing.T) {
+	bytes := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bitBlock := BytesToBitBlock(bytes, 30)
+	original := bitBlock.ToBinaryString()
+	for i := 0; i < bitBlock.Size(); i++ {
+		for j := 0; j < bitBlock.Size(); j++ {
+			// Swap the bits and check that only those bits were exchanged.
+			bitBlock.Swap(i, j)
+			swapped := []byte(original)
+			swapped[i], swapped[j] = swapped[j], swapped[i]
+			if ok := checkBitBlockBinaryString(t, bitBlock, string(swapped)); !ok {
+				t.Fatalf("wrong BitBlock after Swap(%d, %d)", i, j)
+			}
+
+			// Swap them back and check that the BitBlock is unchanged.
+			bitBlock.Swap(i, j)
+			if ok := checkBitBlockBinaryString(t, bitBlock, original); !ok {
+				t.Fatalf("wrong BitBlock after swapping back with Swap(%d, %d)", i, j)
+			}
+		}
+	}
+
+	// Test that Swap() panics if a position is out of range.
+	for _, ij := range [][2]int{ {-1, 0}, {0, -1}, {10, 0}, {0, 10}, {10, 10} } {
+		func() {
+			defer func() {
+				panicMessage := recover()
+				if panicMessage == nil {
+					t.Fatalf("the call to Swap(%d, %d) on a BitBlock of size 10 did not panic", ij[0], ij[1])
+				}
+			}()
+			NewZeroBitBlock(10).Swap(ij[0], ij[1])
+		}()
+	}
+}
+
 // The functions to get a panic message are executed.
 // The message returned by those functions is not checked.
 func TestPanicMessages(t *testing.T) {
@@ -1193,4 +4100,15 @@ func TestPanicMessages(t *testing.T) {
 	panicMessageInvalidRangeOverBitBlock(10, 13, 8)
 	panicMessageInvalidNumberOfBitsToDiscardOverBitBlock(10, 30)
 	panicMessageInvalidBitBlockSizeToConvertToInteger("int32", 64)
+	panicMessageBitBlockTooLargeForUint32Indices(10)
+	panicMessageInvalidEncodedBitBlock("RLE", "cannot read the size")
+	panicMessageNegativePosition(-3)
+	panicMessageInvalidFractionOutOfRange(1.5)
+	panicMessageNonPositiveValue("every", 0)
+	panicMessageDifferentBitBlockSizes(10, 12)
+	panicMessageNoBitBlocks()
+	panicMessageNegativeShift(-2)
+	panicMessageNonPositiveValue("workers", -1)
+	panicMessageDifferentSignatureLengths(3, 4)
+	panicMessageNegativeValue("n", -1)
 }
\ No newline at end of file
@@ -0,0 +1,107 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"io"
+)
+
+
+// bitReaderBufferSize is the number of bytes that a BitReader
+// requests from its underlying reader at a time.
+const bitReaderBufferSize = 64
+
+// A BitReader reads bits one at a time, or in BitBlocks, from an
+// underlying io.Reader, buffering the bytes read from it.
+//
+// The bits of each byte are read starting either from the least
+// significant bit (LSB-first), which is the same order used by
+// BytesToBitBlock, or from the most significant bit (MSB-first),
+// which is the order used by most bit-packed formats.
+type BitReader struct {
+	r io.Reader
+	msbFirst bool
+	buf []byte
+	start int
+	end int
+	// The number of bits of buf[start] that were already read.
+	bitPos int
+}
+
+// NewBitReader returns a new BitReader reading from r. If
+// msbFirst is true, the bits of each byte are read starting from
+// the most significant bit; otherwise they are read starting from
+// the least significant bit.
+func NewBitReader(r io.Reader, msbFirst bool) *BitReader {
+	return &BitReader{
+		r: r,
+		msbFirst: msbFirst,
+		buf: make([]byte, bitReaderBufferSize),
+	}
+}
+
+// fill reads more bytes from the underlying reader when all the
+// buffered bytes were read. It returns io.EOF if the underlying
+// reader has no more bytes, or the error returned by it.
+func (reader *BitReader) fill() error {
+	if reader.start < reader.end {
+		return nil
+	}
+	reader.start, reader.end = 0, 0
+	for {
+		n, err := reader.r.Read(reader.buf)
+		if n > 0 {
+			reader.end = n
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// ReadBit reads the next bit. It returns io.EOF if there are no
+// more bits to read, or the error returned by the underlying
+// reader.
+func (reader *BitReader) ReadBit() (bool, error) {
+	if err := reader.fill(); err != nil {
+		return false, err
+	}
+	shift := reader.bitPos
+	if reader.msbFirst {
+		shift = 7 - reader.bitPos
+	}
+	value := (reader.buf[reader.start] >> shift) & 1 != 0
+	reader.bitPos++
+	if reader.bitPos == 8 {
+		reader.start, reader.bitPos = reader.start + 1, 0
+	}
+	return value, nil
+}
+
+// ReadBits reads the next n bits and returns them as a new
+// BitBlock of size n, in which the bit at position i is the i-th
+// bit read. If there are no more bits to read, ReadBits returns
+// io.EOF, and if there are some bits but fewer than n, it returns
+// io.ErrUnexpectedEOF; in both cases the available bits are
+// consumed. ReadBits panics if n < 0.
+func (reader *BitReader) ReadBits(n int) (*BitBlock, error) {
+	if n < 0 {
+		panic(panicMessageNegativeValue("n", n))
+	}
+	bitBlock := NewZeroBitBlock(n)
+	for pos := 0; pos < n; pos++ {
+		value, err := reader.ReadBit()
+		if err != nil {
+			if err == io.EOF && pos > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if value {
+			bitBlock.bits[pos >> 3] |= 1 << (pos & 7)
+		}
+	}
+	return bitBlock, nil
+}
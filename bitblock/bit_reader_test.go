@@ -0,0 +1,94 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+
+// Test the ReadBit() and ReadBits() methods.
+func TestBitReader(t *testing.T) {
+	data := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	bitBlock := BytesToBitBlock(data, 8 * len(data))
+
+	// Test reading LSB-first bit by bit, also with a reader that
+	// returns one byte at a time, which reassembles the BitBlock.
+	for _, r := range []io.Reader{ bytes.NewReader(data), iotest.OneByteReader(bytes.NewReader(data)) } {
+		reader := NewBitReader(r, false)
+		for pos := 0; pos < bitBlock.Size(); pos++ {
+			value, err := reader.ReadBit()
+			if err != nil {
+				t.Fatalf("got error %q from ReadBit() at position %d, want nil", err, pos)
+			}
+			if value != bitBlock.Get(pos) {
+				t.Fatalf("got ReadBit() = %t at position %d, want %t", value, pos, bitBlock.Get(pos))
+			}
+		}
+		if _, err := reader.ReadBit(); err != io.EOF {
+			t.Fatalf("got error %v from ReadBit() at the end, want %v", err, io.EOF)
+		}
+	}
+
+	// Test reading MSB-first, where the bits of each byte are reversed.
+	reader := NewBitReader(bytes.NewReader(data), true)
+	for pos := 0; pos < bitBlock.Size(); pos++ {
+		value, err := reader.ReadBit()
+		if err != nil {
+			t.Fatalf("got error %q from ReadBit() at position %d, want nil", err, pos)
+		}
+		if want := bitBlock.Get(8 * (pos / 8) + 7 - (pos & 7)); value != want {
+			t.Fatalf("got ReadBit() = %t at position %d reading MSB-first, want %t", value, pos, want)
+		}
+	}
+
+	// Test that reading blocks of several sizes reassembles the BitBlock.
+	for _, n := range []int{1, 4, 8, 13, 26, 104} {
+		reader := NewBitReader(bytes.NewReader(data), false)
+		blocks := []*BitBlock{}
+		for pos := 0; pos < bitBlock.Size(); pos += n {
+			block, err := reader.ReadBits(n)
+			if err != nil {
+				t.Fatalf("got error %q from ReadBits(%d) at position %d, want nil", err, n, pos)
+			}
+			blocks = append(blocks, block)
+		}
+		if ok := checkBitBlockBinaryString(t, Concatenate(blocks...), bitBlock.ToBinaryString()); !ok {
+			t.Fatalf("wrong BitBlock after reading blocks of %d bits", n)
+		}
+	}
+
+	// Test the errors at the end of the stream.
+	reader = NewBitReader(bytes.NewReader(data[:2]), false)
+	if block, err := reader.ReadBits(10); err != nil || block.Size() != 10 {
+		t.Fatalf("got error %v from ReadBits(10) with 16 bits available, want nil", err)
+	}
+	if block, err := reader.ReadBits(0); err != nil || block.Size() != 0 {
+		t.Fatalf("got error %v from ReadBits(0), want nil", err)
+	}
+	if _, err := reader.ReadBits(10); err != io.ErrUnexpectedEOF {
+		t.Fatalf("got error %v from ReadBits(10) with 6 bits available, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if _, err := reader.ReadBits(1); err != io.EOF {
+		t.Fatalf("got error %v from ReadBits(1) with no bits available, want %v", err, io.EOF)
+	}
+	reader = NewBitReader(iotest.ErrReader(io.ErrClosedPipe), false)
+	if _, err := reader.ReadBit(); err != io.ErrClosedPipe {
+		t.Fatalf("got error %v from ReadBit() on a failing reader, want %v", err, io.ErrClosedPipe)
+	}
+
+	// Test that ReadBits() panics if n < 0.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to ReadBits(-1) did not panic")
+			}
+		}()
+		NewBitReader(bytes.NewReader(data), false).ReadBits(-1)
+	}()
+}
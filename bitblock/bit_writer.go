@@ -0,0 +1,115 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"io"
+)
+
+
+// bitWriterBufferSize is the number of complete bytes that a
+// BitWriter buffers before writing them to its underlying writer.
+const bitWriterBufferSize = 64
+
+// A BitWriter writes bits one at a time, or in BitBlocks, to an
+// underlying io.Writer, buffering the bytes before writing them.
+// The bits are packed into bytes in the same orders supported by
+// BitReader (LSB-first or MSB-first).
+//
+// After all the bits are written, Flush must be called to write
+// the buffered bytes, including the last partial byte, whose
+// remaining bits are set to 0. If an error occurs, no more bits
+// are accepted and all the following calls return that error.
+type BitWriter struct {
+	w io.Writer
+	msbFirst bool
+	buf []byte
+	// The partial byte being filled and its number of bits.
+	current byte
+	bitPos int
+	err error
+}
+
+// NewBitWriter returns a new BitWriter writing to w. If msbFirst
+// is true, the bits of each byte are written starting from the
+// most significant bit; otherwise they are written starting from
+// the least significant bit.
+func NewBitWriter(w io.Writer, msbFirst bool) *BitWriter {
+	return &BitWriter{
+		w: w,
+		msbFirst: msbFirst,
+		buf: make([]byte, 0, bitWriterBufferSize),
+	}
+}
+
+// writeBuffer writes the buffered complete bytes to the
+// underlying writer.
+func (writer *BitWriter) writeBuffer() error {
+	if writer.err != nil {
+		return writer.err
+	}
+	n, err := writer.w.Write(writer.buf)
+	if err == nil && n < len(writer.buf) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		writer.err = err
+		return err
+	}
+	writer.buf = writer.buf[:0]
+	return nil
+}
+
+// WriteBit writes a bit with the given value. It returns the error
+// of the underlying writer, if the buffer had to be written.
+func (writer *BitWriter) WriteBit(value bool) error {
+	if writer.err != nil {
+		return writer.err
+	}
+	if value {
+		shift := writer.bitPos
+		if writer.msbFirst {
+			shift = 7 - writer.bitPos
+		}
+		writer.current |= 1 << shift
+	}
+	writer.bitPos++
+	if writer.bitPos == 8 {
+		writer.buf = append(writer.buf, writer.current)
+		writer.current, writer.bitPos = 0, 0
+		if len(writer.buf) == cap(writer.buf) {
+			return writer.writeBuffer()
+		}
+	}
+	return nil
+}
+
+// WriteBitBlock writes the bits of bitBlock, from position 0 to
+// position bitBlock.Size()-1. It returns the error of the
+// underlying writer, if the buffer had to be written.
+func (writer *BitWriter) WriteBitBlock(bitBlock *BitBlock) error {
+	for pos := 0; pos < bitBlock.size; pos++ {
+		if err := writer.WriteBit(bitBlock.Get(pos)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush writes all the buffered bytes to the underlying writer,
+// including the partial byte, if any, with its remaining bits set
+// to 0. The next bit written after Flush starts a new byte.
+func (writer *BitWriter) Flush() error {
+	if writer.err != nil {
+		return writer.err
+	}
+	if writer.bitPos > 0 {
+		writer.buf = append(writer.buf, writer.current)
+		writer.current, writer.bitPos = 0, 0
+	}
+	if len(writer.buf) == 0 {
+		return nil
+	}
+	return writer.writeBuffer()
+}
@@ -0,0 +1,85 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+
+// Test the WriteBit(), WriteBitBlock() and Flush() methods.
+func TestBitWriter(t *testing.T) {
+	data := []byte{15, 54, 127, 200, 0, 15, 95, 128, 127, 34, 19, 183, 255}
+	for _, msbFirst := range []bool{false, true} {
+		for _, size := range []int{0, 1, 7, 8, 9, 61, 104} {
+			bitBlock := BytesToBitBlock(data, size)
+
+			// Write the bits one at a time and then as BitBlocks, with
+			// enough bytes to write the buffer several times.
+			var buf bytes.Buffer
+			writer := NewBitWriter(&buf, msbFirst)
+			for pos := 0; pos < size; pos++ {
+				if err := writer.WriteBit(bitBlock.Get(pos)); err != nil {
+					t.Fatalf("got error %q from WriteBit(), want nil", err)
+				}
+			}
+			for i := 0; i < 20; i++ {
+				if err := writer.WriteBitBlock(bitBlock); err != nil {
+					t.Fatalf("got error %q from WriteBitBlock(), want nil", err)
+				}
+			}
+			if err := writer.Flush(); err != nil {
+				t.Fatalf("got error %q from Flush(), want nil", err)
+			}
+			total := 21 * size
+			if buf.Len() != (total + 7) / 8 {
+				t.Fatalf("got %d bytes after writing %d bits, want %d", buf.Len(), total, (total + 7) / 8)
+			}
+
+			// Read the bits back with a BitReader.
+			reader := NewBitReader(&buf, msbFirst)
+			result, err := reader.ReadBits(total)
+			if err != nil {
+				t.Fatalf("got error %q from ReadBits(%d), want nil", err, total)
+			}
+			if ok := checkBitBlockBinaryString(t, result, bitBlock.Repeat(21).ToBinaryString()); !ok {
+				t.Fatalf("wrong bits read back after writing a BitBlock of size %d with msbFirst = %t", size, msbFirst)
+			}
+
+			// The padding bits of the last byte are 0.
+			for pos := total; pos < 8 * ((total + 7) / 8); pos++ {
+				if value, err := reader.ReadBit(); err != nil || value {
+					t.Fatalf("got ReadBit() = %t, %v on a padding bit, want false, nil", value, err)
+				}
+			}
+		}
+	}
+
+	// Test that the bytes are laid out as with BytesToBitBlock when
+	// writing LSB-first, and that Flush starts a new byte.
+	var buf bytes.Buffer
+	writer := NewBitWriter(&buf, false)
+	writer.WriteBitBlock(binaryStringToBitBlock("111"))
+	writer.Flush()
+	writer.WriteBitBlock(binaryStringToBitBlock("0000000011"))
+	writer.Flush()
+	if want := []byte{7, 0, 3}; !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("got bytes %v, want %v", buf.Bytes(), want)
+	}
+
+	// Test that the errors of the underlying writer are returned by
+	// all the following calls.
+	writer = NewBitWriter(&shortWriter{ limit: 0, err: io.ErrClosedPipe }, false)
+	if err := writer.WriteBitBlock(BytesToBitBlock(data, 5)); err != nil {
+		t.Fatalf("got error %v from WriteBitBlock() before writing the buffer, want nil", err)
+	}
+	if err := writer.Flush(); err != io.ErrClosedPipe {
+		t.Fatalf("got error %v from Flush(), want %v", err, io.ErrClosedPipe)
+	}
+	if err := writer.WriteBit(true); err != io.ErrClosedPipe {
+		t.Fatalf("got error %v from WriteBit() after an error, want %v", err, io.ErrClosedPipe)
+	}
+}
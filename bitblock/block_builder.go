@@ -0,0 +1,55 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+// A BlockBuilder is used to assemble a BitBlock from several
+// writes of sub-blocks at arbitrary positions, which may overlap.
+// When two writes overlap, the bits of the last write are kept.
+//
+// The BitBlock being built grows automatically to contain all
+// the written bits, so it is not necessary to know its final
+// size in advance. The zero value of a BlockBuilder is an empty
+// builder ready to use.
+type BlockBuilder struct {
+	block BitBlock
+}
+
+// NewBlockBuilder returns a new empty BlockBuilder.
+func NewBlockBuilder() *BlockBuilder {
+	return &BlockBuilder{}
+}
+
+// WriteAt writes the bits of value starting at position pos,
+// overwriting any bits previously written at those positions.
+// If the bits do not fit in the BitBlock being built, it grows
+// so that its size is pos + value.Size(), and the bits that were
+// never written are set to 0. WriteAt panics if pos < 0.
+func (builder *BlockBuilder) WriteAt(pos int, value *BitBlock) {
+	if pos < 0 {
+		panic(panicMessageNegativePosition(pos))
+	}
+	block := &builder.block
+	if size := pos + value.Size(); size > block.size {
+		for len(block.bits) < (size + 7) / 8 {
+			block.bits = append(block.bits, 0)
+		}
+		block.size = size
+	}
+	for i := 0; i < value.Size(); i++ {
+		block.Set(pos + i, value.Get(i))
+	}
+}
+
+// Size returns the size of the BitBlock being built, which is
+// the furthest end position among all the writes.
+func (builder *BlockBuilder) Size() int {
+	return builder.block.size
+}
+
+// Build returns a new BitBlock containing a copy of the bits
+// written so far. The builder can still be used after calling
+// Build, without affecting the returned BitBlock.
+func (builder *BlockBuilder) Build() *BitBlock {
+	return builder.block.Clone()
+}
@@ -0,0 +1,78 @@
+// LICENCE NOT YET DEFINED.
+
+package bitblock
+
+
+import (
+	"testing"
+)
+
+
+// Test the BlockBuilder type.
+func TestBlockBuilder(t *testing.T) {
+	type Write struct { pos int; value string }
+	type Test struct { id string; writes []Write; result string }
+
+	// Test cases.
+	tests := []Test{
+		Test{
+			id: "0000",
+			writes: []Write{ Write{0, "1111"}, Write{2, "000"}, Write{1, "1"} },
+			result: "11000",
+		},
+		Test{
+			id: "0001",
+			writes: []Write{ Write{10, "101"}, Write{3, "11"}, Write{11, "1"} },
+			result: "0001100000111",
+		},
+		Test{
+			id: "0002",
+			writes: []Write{ Write{5, "11111111111111111111"}, Write{0, "1010101010"}, Write{20, "0"} },
+			result: "1010101010111111111101111",
+		},
+		Test{
+			id: "0003",
+			writes: []Write{ Write{0, "1111111111"}, Write{3, ""}, Write{9, "0"} },
+			result: "1111111110",
+		},
+		Test{
+			id: "0004",
+			writes: []Write{},
+			result: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			builder := NewBlockBuilder()
+			for _, write := range test.writes {
+				builder.WriteAt(write.pos, binaryStringToBitBlock(write.value))
+			}
+			if size := builder.Size(); size != len(test.result) {
+				t.Fatalf("got builder.Size() = %d, want builder.Size() = %d", size, len(test.result))
+			}
+			bitBlock := builder.Build()
+			if ok := checkBitBlockBinaryString(t, bitBlock, test.result); !ok {
+				t.Fatalf("wrong BitBlock built from the writes %v, want %q", test.writes, test.result)
+			}
+
+			// Test that later writes do not affect the BitBlock already built.
+			builder.WriteAt(0, binaryStringToBitBlock("1111"))
+			if ok := checkBitBlockBinaryString(t, bitBlock, test.result); !ok {
+				t.Fatalf("the BitBlock returned by Build() was modified by a later write")
+			}
+		})
+	}
+
+	// Test that WriteAt() panics if the position is negative.
+	func() {
+		defer func() {
+			panicMessage := recover()
+			if panicMessage == nil {
+				t.Fatalf("the call to WriteAt(-1, value) did not panic")
+			}
+		}()
+		var builder BlockBuilder
+		builder.WriteAt(-1, binaryStringToBitBlock("1"))
+	}()
+}